@@ -0,0 +1,406 @@
+// Package abi implements the Solidity contract ABI: parsing of JSON and human-readable
+// definitions, and encoding/decoding of values.
+package abi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ABI holds the definition of a contract interface
+type ABI struct {
+	Constructor *Method
+	Fallback    *Method
+	Receive     *Method
+	Methods     []*Method
+	Events      []*Event
+	Errors      []*Error
+}
+
+type jsonArgument struct {
+	Name         string          `json:"name"`
+	Type         string          `json:"type"`
+	InternalType string          `json:"internalType,omitempty"`
+	Indexed      bool            `json:"indexed,omitempty"`
+	Components   []*jsonArgument `json:"components,omitempty"`
+}
+
+type jsonEntry struct {
+	Type            string          `json:"type"`
+	Name            string          `json:"name"`
+	Inputs          []*jsonArgument `json:"inputs"`
+	Outputs         []*jsonArgument `json:"outputs"`
+	StateMutability string          `json:"stateMutability"`
+	Anonymous       bool            `json:"anonymous"`
+	Constant        bool            `json:"constant"` // old solidity versions
+	Payable         bool            `json:"payable"`  // old solidity versions
+}
+
+// Parse reads a JSON ABI definition from r
+func Parse(r io.Reader) (*ABI, error) {
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJSON(buf)
+}
+
+// ParseJSON parses a JSON ABI definition. Build artifacts containing the ABI in an "abi"
+// property are also accepted.
+func ParseJSON(buf []byte) (*ABI, error) {
+	res := &ABI{}
+	err := json.Unmarshal(buf, res)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// MustParseJSON is the same as ParseJSON but panics on error
+func MustParseJSON(s string) *ABI {
+	res, err := ParseJSON([]byte(s))
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// UnmarshalJSON allows an ABI to be decoded directly from its JSON definition
+func (a *ABI) UnmarshalJSON(buf []byte) error {
+	buf = bytes.TrimSpace(buf)
+	if len(buf) > 0 && buf[0] == '{' {
+		// build artifact (hardhat, foundry, truffle...)
+		var artifact struct {
+			ABI json.RawMessage `json:"abi"`
+		}
+		if err := json.Unmarshal(buf, &artifact); err != nil {
+			return err
+		}
+		if artifact.ABI == nil {
+			return fmt.Errorf("%w: no abi found in object", ErrInvalidType)
+		}
+		buf = artifact.ABI
+	}
+
+	var entries []*jsonEntry
+	if err := json.Unmarshal(buf, &entries); err != nil {
+		return err
+	}
+
+	*a = ABI{}
+	for _, e := range entries {
+		inputs, err := jsonArguments(e.Inputs)
+		if err != nil {
+			return fmt.Errorf("%s: %w", e.Name, err)
+		}
+		switch e.Type {
+		case "function", "", "constructor", "fallback", "receive":
+			outputs, err := jsonArguments(e.Outputs)
+			if err != nil {
+				return fmt.Errorf("%s: %w", e.Name, err)
+			}
+			m := &Method{
+				Name:            e.Name,
+				Type:            e.Type,
+				Inputs:          inputs,
+				Outputs:         outputs,
+				StateMutability: e.StateMutability,
+			}
+			if m.Type == "" {
+				m.Type = "function"
+			}
+			if m.StateMutability == "" {
+				switch {
+				case e.Constant:
+					m.StateMutability = "view"
+				case e.Payable:
+					m.StateMutability = "payable"
+				default:
+					m.StateMutability = "nonpayable"
+				}
+			}
+			a.addMethod(m)
+		case "event":
+			a.Events = append(a.Events, &Event{Name: e.Name, Inputs: inputs, Anonymous: e.Anonymous})
+		case "error":
+			a.Errors = append(a.Errors, &Error{Name: e.Name, Inputs: inputs})
+		default:
+			return fmt.Errorf("%w: unknown abi entry type %q", ErrInvalidType, e.Type)
+		}
+	}
+	return nil
+}
+
+func (a *ABI) addMethod(m *Method) {
+	switch m.Type {
+	case "constructor":
+		a.Constructor = m
+	case "fallback":
+		a.Fallback = m
+	case "receive":
+		a.Receive = m
+	default:
+		a.Methods = append(a.Methods, m)
+	}
+}
+
+func jsonArguments(list []*jsonArgument) (Arguments, error) {
+	res := make(Arguments, 0, len(list))
+	for _, a := range list {
+		t, err := jsonType(a)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &Argument{Name: a.Name, Type: t, Indexed: a.Indexed, InternalType: a.InternalType})
+	}
+	return res, nil
+}
+
+// jsonType builds the type of a JSON ABI argument, which for tuples is found in its components
+func jsonType(a *jsonArgument) (*Type, error) {
+	if !strings.HasPrefix(a.Type, "tuple") {
+		return ParseType(a.Type)
+	}
+	comps, err := jsonArguments(a.Components)
+	if err != nil {
+		return nil, err
+	}
+	// replace "tuple" with the actual definition and parse again to handle array suffixes
+	t := &Type{Kind: TupleKind, Components: comps}
+	suffix := a.Type[5:]
+	for suffix != "" {
+		pos := strings.IndexByte(suffix, ']')
+		if suffix[0] != '[' || pos == -1 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidType, a.Type)
+		}
+		arr, err := ParseType("bool" + suffix[:pos+1])
+		if err != nil {
+			return nil, err
+		}
+		arr.Elem = t
+		t = arr
+		suffix = suffix[pos+1:]
+	}
+	return t, nil
+}
+
+// ParseHuman parses human-readable ABI definitions such as:
+//
+//	function balanceOf(address owner) view returns (uint256)
+//	event Transfer(address indexed from, address indexed to, uint256 value)
+//	error InsufficientBalance(uint256 available, uint256 required)
+//	constructor(string name, string symbol)
+func ParseHuman(sigs ...string) (*ABI, error) {
+	res := &ABI{}
+	for _, s := range sigs {
+		if err := res.parseHuman(s); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// MustParseHuman is the same as ParseHuman but panics on error
+func MustParseHuman(sigs ...string) *ABI {
+	res, err := ParseHuman(sigs...)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// ParseMethod parses a single human-readable function definition
+func ParseMethod(s string) (*Method, error) {
+	a, err := ParseHuman(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(a.Methods) != 1 {
+		return nil, fmt.Errorf("%w: not a function: %s", ErrInvalidType, s)
+	}
+	return a.Methods[0], nil
+}
+
+// ParseEvent parses a single human-readable event definition
+func ParseEvent(s string) (*Event, error) {
+	a, err := ParseHuman(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(a.Events) != 1 {
+		return nil, fmt.Errorf("%w: not an event: %s", ErrInvalidType, s)
+	}
+	return a.Events[0], nil
+}
+
+// ParseError parses a single human-readable error definition
+func ParseError(s string) (*Error, error) {
+	a, err := ParseHuman(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(a.Errors) != 1 {
+		return nil, fmt.Errorf("%w: not an error: %s", ErrInvalidType, s)
+	}
+	return a.Errors[0], nil
+}
+
+func (a *ABI) parseHuman(s string) error {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), ";"))
+	open := strings.IndexByte(s, '(')
+	if open == -1 {
+		return fmt.Errorf("%w: missing parameters in %s", ErrInvalidType, s)
+	}
+	closing, err := matchParen(s, open)
+	if err != nil {
+		return err
+	}
+
+	head := strings.Fields(s[:open])
+	var kind, name string
+	switch len(head) {
+	case 1:
+		switch head[0] {
+		case "constructor", "fallback", "receive":
+			kind = head[0]
+		default:
+			kind, name = "function", head[0]
+		}
+	case 2:
+		kind, name = head[0], head[1]
+	default:
+		return fmt.Errorf("%w: invalid definition %s", ErrInvalidType, s)
+	}
+
+	inputs, err := parseParams(s[open+1:closing], kind == "event")
+	if err != nil {
+		return err
+	}
+	rest := strings.TrimSpace(s[closing+1:])
+
+	switch kind {
+	case "event":
+		ev := &Event{Name: name, Inputs: inputs}
+		switch rest {
+		case "":
+		case "anonymous":
+			ev.Anonymous = true
+		default:
+			return fmt.Errorf("%w: unexpected %q in %s", ErrInvalidType, rest, s)
+		}
+		a.Events = append(a.Events, ev)
+		return nil
+	case "error":
+		if rest != "" {
+			return fmt.Errorf("%w: unexpected %q in %s", ErrInvalidType, rest, s)
+		}
+		a.Errors = append(a.Errors, &Error{Name: name, Inputs: inputs})
+		return nil
+	case "function", "constructor", "fallback", "receive":
+	default:
+		return fmt.Errorf("%w: unknown definition type %q", ErrInvalidType, kind)
+	}
+
+	m := &Method{Name: name, Type: kind, Inputs: inputs, StateMutability: "nonpayable"}
+	for rest != "" {
+		var word string
+		word, rest, _ = strings.Cut(rest, " ")
+		rest = strings.TrimSpace(rest)
+		switch {
+		case word == "view" || word == "pure" || word == "payable" || word == "nonpayable":
+			m.StateMutability = word
+		case word == "constant":
+			m.StateMutability = "view"
+		case word == "external" || word == "public" || word == "virtual" || word == "override":
+			// visibility, irrelevant
+		case word == "returns" || strings.HasPrefix(word, "returns("):
+			ret := strings.TrimSpace(strings.TrimPrefix(word, "returns") + " " + rest)
+			if !strings.HasPrefix(ret, "(") {
+				return fmt.Errorf("%w: invalid returns in %s", ErrInvalidType, s)
+			}
+			end, err := matchParen(ret, 0)
+			if err != nil {
+				return err
+			}
+			m.Outputs, err = parseParams(ret[1:end], false)
+			if err != nil {
+				return err
+			}
+			rest = strings.TrimSpace(ret[end+1:])
+		default:
+			return fmt.Errorf("%w: unexpected %q in %s", ErrInvalidType, word, s)
+		}
+	}
+	a.addMethod(m)
+	return nil
+}
+
+// Method returns the method with the given name or signature, or nil if not found. If
+// a function is overloaded, its signature (such as "transfer(address,uint256)") should be used.
+func (a *ABI) Method(name string) *Method {
+	for _, m := range a.Methods {
+		if m.Name == name || m.Sig() == name {
+			return m
+		}
+	}
+	return nil
+}
+
+// MethodBySelector returns the method matching the given 4 bytes selector
+func (a *ABI) MethodBySelector(sel []byte) *Method {
+	if len(sel) < 4 {
+		return nil
+	}
+	for _, m := range a.Methods {
+		if bytes.Equal(m.Selector(), sel[:4]) {
+			return m
+		}
+	}
+	return nil
+}
+
+// Event returns the event with the given name or signature, or nil if not found
+func (a *ABI) Event(name string) *Event {
+	for _, e := range a.Events {
+		if e.Name == name || e.Sig() == name {
+			return e
+		}
+	}
+	return nil
+}
+
+// EventByID returns the event matching the given topic hash
+func (a *ABI) EventByID(id Hash) *Event {
+	for _, e := range a.Events {
+		if !e.Anonymous && e.ID() == id {
+			return e
+		}
+	}
+	return nil
+}
+
+// Error returns the error with the given name or signature, or nil if not found
+func (a *ABI) Error(name string) *Error {
+	for _, e := range a.Errors {
+		if e.Name == name || e.Sig() == name {
+			return e
+		}
+	}
+	return nil
+}
+
+// ErrorBySelector returns the error matching the given 4 bytes selector
+func (a *ABI) ErrorBySelector(sel []byte) *Error {
+	if len(sel) < 4 {
+		return nil
+	}
+	for _, e := range a.Errors {
+		if bytes.Equal(e.Selector(), sel[:4]) {
+			return e
+		}
+	}
+	return nil
+}
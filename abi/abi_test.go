@@ -0,0 +1,170 @@
+package abi
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// unhex decodes a hex string, ignoring whitespace
+func unhex(t *testing.T, s string) []byte {
+	t.Helper()
+	buf, err := hex.DecodeString(strings.Join(strings.Fields(strings.TrimPrefix(s, "0x")), ""))
+	if err != nil {
+		t.Fatalf("invalid test vector: %s", err)
+	}
+	return buf
+}
+
+// vectors from the examples of the Solidity ABI specification
+func TestPackSpecExamples(t *testing.T) {
+	tests := []struct {
+		sig  string
+		args []any
+		want string
+	}{
+		{
+			"function baz(uint32 x, bool y)",
+			[]any{uint32(69), true},
+			`cdcd77c0
+			0000000000000000000000000000000000000000000000000000000000000045
+			0000000000000000000000000000000000000000000000000000000000000001`,
+		},
+		{
+			"function sam(bytes, bool, uint256[])",
+			[]any{[]byte("dave"), true, []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}},
+			`a5643bf2
+			0000000000000000000000000000000000000000000000000000000000000060
+			0000000000000000000000000000000000000000000000000000000000000001
+			00000000000000000000000000000000000000000000000000000000000000a0
+			0000000000000000000000000000000000000000000000000000000000000004
+			6461766500000000000000000000000000000000000000000000000000000000
+			0000000000000000000000000000000000000000000000000000000000000003
+			0000000000000000000000000000000000000000000000000000000000000001
+			0000000000000000000000000000000000000000000000000000000000000002
+			0000000000000000000000000000000000000000000000000000000000000003`,
+		},
+		{
+			"function f(uint256, uint32[], bytes10, bytes)",
+			[]any{big.NewInt(0x123), []uint32{0x456, 0x789}, [10]byte([]byte("1234567890")), []byte("Hello, world!")},
+			`8be65246
+			0000000000000000000000000000000000000000000000000000000000000123
+			0000000000000000000000000000000000000000000000000000000000000080
+			3132333435363738393000000000000000000000000000000000000000000000
+			00000000000000000000000000000000000000000000000000000000000000e0
+			0000000000000000000000000000000000000000000000000000000000000002
+			0000000000000000000000000000000000000000000000000000000000000456
+			0000000000000000000000000000000000000000000000000000000000000789
+			000000000000000000000000000000000000000000000000000000000000000d
+			48656c6c6f2c20776f726c642100000000000000000000000000000000000000`,
+		},
+		{
+			"function g(uint256[][], string[])",
+			[]any{[][]*big.Int{{big.NewInt(1), big.NewInt(2)}, {big.NewInt(3)}}, []string{"one", "two", "three"}},
+			`2289b18c
+			0000000000000000000000000000000000000000000000000000000000000040
+			0000000000000000000000000000000000000000000000000000000000000140
+			0000000000000000000000000000000000000000000000000000000000000002
+			0000000000000000000000000000000000000000000000000000000000000040
+			00000000000000000000000000000000000000000000000000000000000000a0
+			0000000000000000000000000000000000000000000000000000000000000002
+			0000000000000000000000000000000000000000000000000000000000000001
+			0000000000000000000000000000000000000000000000000000000000000002
+			0000000000000000000000000000000000000000000000000000000000000001
+			0000000000000000000000000000000000000000000000000000000000000003
+			0000000000000000000000000000000000000000000000000000000000000003
+			0000000000000000000000000000000000000000000000000000000000000060
+			00000000000000000000000000000000000000000000000000000000000000a0
+			00000000000000000000000000000000000000000000000000000000000000e0
+			0000000000000000000000000000000000000000000000000000000000000003
+			6f6e650000000000000000000000000000000000000000000000000000000000
+			0000000000000000000000000000000000000000000000000000000000000003
+			74776f0000000000000000000000000000000000000000000000000000000000
+			0000000000000000000000000000000000000000000000000000000000000005
+			7468726565000000000000000000000000000000000000000000000000000000`,
+		},
+	}
+
+	for _, test := range tests {
+		m, err := ParseMethod(test.sig)
+		if err != nil {
+			t.Fatalf("%s: %s", test.sig, err)
+		}
+		want := unhex(t, test.want)
+		got, err := m.Pack(test.args...)
+		if err != nil {
+			t.Errorf("%s: pack failed: %s", m.Sig(), err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: got %x, want %x", m.Sig(), got, want)
+			continue
+		}
+
+		// decoding then encoding again must give the same result
+		values, err := m.UnpackInputs(want)
+		if err != nil {
+			t.Errorf("%s: unpack failed: %s", m.Sig(), err)
+			continue
+		}
+		again, err := m.Pack(values...)
+		if err != nil || !bytes.Equal(again, want) {
+			t.Errorf("%s: round trip gave %x (%v)", m.Sig(), again, err)
+		}
+	}
+}
+
+func TestPackInvalidValue(t *testing.T) {
+	m, err := ParseMethod("function baz(uint32 x, bool y)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []any{nil, (*big.Int)(nil), "abc", 1.5} {
+		if _, err := m.Pack(v, true); !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("packing %#v: got error %v, want ErrInvalidValue", v, err)
+		}
+	}
+	if _, err := MustParseType("uint256").Pack(nil); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("packing nil: got error %v, want ErrInvalidValue", err)
+	}
+	if _, err := m.Pack(int64(1)<<32, true); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("packing an overflowing uint32: got error %v, want ErrInvalidValue", err)
+	}
+}
+
+// vector from the Solidity documentation of abi.encodePacked
+func TestEncodePacked(t *testing.T) {
+	got, err := EncodePacked([]string{"int16", "bytes1", "uint16", "string"}, int16(-1), []byte{0x42}, uint16(3), "Hello, world!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := unhex(t, "ffff42000348656c6c6f2c20776f726c6421")
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+}
+
+// vectors from EIP-55
+func TestAddressChecksum(t *testing.T) {
+	for _, s := range []string{
+		"0x52908400098527886E0F7030069857D2E4169EE7",
+		"0x8617E340B3D01FA5F11F306F4090FD50E238070D",
+		"0xde709f2102306220921060314715629080e2fb77",
+		"0x27b1fdb04752bbc536007a920d24acb045561c26",
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	} {
+		a, err := ParseAddress(strings.ToLower(s))
+		if err != nil {
+			t.Fatalf("%s: %s", s, err)
+		}
+		if got := a.Hex(); got != s {
+			t.Errorf("got %s, want %s", got, s)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package abi
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Address is a 20 bytes Ethereum address
+type Address [20]byte
+
+// Hash is a 32 bytes value, typically the result of a Keccak-256 hash
+type Hash [32]byte
+
+var (
+	ErrInvalidAddress = errors.New("invalid address")
+	ErrInvalidHash    = errors.New("invalid hash")
+)
+
+// ParseAddress decodes a hex-encoded address, with or without the 0x prefix. The checksum is
+// not verified.
+func ParseAddress(s string) (Address, error) {
+	var res Address
+	buf, err := hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X"))
+	if err != nil || len(buf) != len(res) {
+		return res, fmt.Errorf("%w: %q", ErrInvalidAddress, s)
+	}
+	copy(res[:], buf)
+	return res, nil
+}
+
+// MustParseAddress is the same as ParseAddress but panics on error. It is meant to be used for
+// constants.
+func MustParseAddress(s string) Address {
+	res, err := ParseAddress(s)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// Hex returns the EIP-55 checksummed representation of the address
+func (a Address) Hex() string {
+	buf := []byte(hex.EncodeToString(a[:]))
+	h := Keccak256(buf)
+	for i, c := range buf {
+		if c < 'a' {
+			continue
+		}
+		// uppercase letters where the matching nibble of the hash is >= 8
+		nibble := h[i/2]
+		if i%2 == 0 {
+			nibble >>= 4
+		}
+		if nibble&0xf >= 8 {
+			buf[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(buf)
+}
+
+// String returns the address as a checksummed hex string
+func (a Address) String() string {
+	return a.Hex()
+}
+
+// IsZero returns true if the address is the zero address
+func (a Address) IsZero() bool {
+	return a == Address{}
+}
+
+func (a Address) MarshalText() ([]byte, error) {
+	return []byte(a.Hex()), nil
+}
+
+func (a *Address) UnmarshalText(b []byte) error {
+	v, err := ParseAddress(string(b))
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+// ParseHash decodes a hex-encoded 32 bytes hash, with or without the 0x prefix
+func ParseHash(s string) (Hash, error) {
+	var res Hash
+	buf, err := hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X"))
+	if err != nil || len(buf) != len(res) {
+		return res, fmt.Errorf("%w: %q", ErrInvalidHash, s)
+	}
+	copy(res[:], buf)
+	return res, nil
+}
+
+// Hex returns the hash as a 0x-prefixed hex string
+func (h Hash) Hex() string {
+	return "0x" + hex.EncodeToString(h[:])
+}
+
+func (h Hash) String() string {
+	return h.Hex()
+}
+
+func (h Hash) MarshalText() ([]byte, error) {
+	return []byte(h.Hex()), nil
+}
+
+func (h *Hash) UnmarshalText(b []byte) error {
+	v, err := ParseHash(string(b))
+	if err != nil {
+		return err
+	}
+	*h = v
+	return nil
+}
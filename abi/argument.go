@@ -0,0 +1,187 @@
+package abi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Argument is a named parameter of a method, event or error
+type Argument struct {
+	Name         string
+	Type         *Type
+	Indexed      bool   // only for events
+	InternalType string // type name used by the solidity compiler, such as "struct Pool.Key", if known
+}
+
+// Arguments is a list of arguments, such as the inputs or outputs of a method
+type Arguments []*Argument
+
+// String returns the argument in human-readable format, such as "address indexed from"
+func (a *Argument) String() string {
+	res := a.Type.String()
+	if a.Indexed {
+		res += " indexed"
+	}
+	if a.Name != "" {
+		res += " " + a.Name
+	}
+	return res
+}
+
+// typeList returns the comma separated list of canonical types, as used in signatures
+func (args Arguments) typeList() string {
+	res := make([]string, len(args))
+	for n, a := range args {
+		res[n] = a.Type.String()
+	}
+	return strings.Join(res, ",")
+}
+
+// String returns the arguments in human-readable format
+func (args Arguments) String() string {
+	res := make([]string, len(args))
+	for n, a := range args {
+		res[n] = a.String()
+	}
+	return strings.Join(res, ", ")
+}
+
+// NonIndexed returns the arguments that are not indexed
+func (args Arguments) NonIndexed() Arguments {
+	var res Arguments
+	for _, a := range args {
+		if !a.Indexed {
+			res = append(res, a)
+		}
+	}
+	return res
+}
+
+// Types returns the arguments as a tuple type
+func (args Arguments) Types() *Type {
+	return &Type{Kind: TupleKind, Components: args}
+}
+
+// parseParams parses a human-readable comma separated parameter list such as
+// "address indexed from, uint256 value"
+func parseParams(s string, allowIndexed bool) (Arguments, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	parts, err := splitTopLevel(s)
+	if err != nil {
+		return nil, err
+	}
+	res := make(Arguments, 0, len(parts))
+	for _, p := range parts {
+		arg, err := parseParam(p, allowIndexed)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, arg)
+	}
+	return res, nil
+}
+
+// parseParam parses a single parameter such as "uint256[] memory amounts"
+func parseParam(s string, allowIndexed bool) (*Argument, error) {
+	s = strings.TrimSpace(s)
+	var typ, rest string
+
+	if strings.HasPrefix(s, "(") || strings.HasPrefix(s, "tuple(") {
+		end, err := matchParen(s, strings.IndexByte(s, '('))
+		if err != nil {
+			return nil, err
+		}
+		// include array suffixes
+		end += 1
+		for end < len(s) && s[end] == '[' {
+			pos := strings.IndexByte(s[end:], ']')
+			if pos == -1 {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidType, s)
+			}
+			end += pos + 1
+		}
+		typ, rest = s[:end], s[end:]
+	} else {
+		typ, rest, _ = strings.Cut(s, " ")
+		if i := strings.IndexAny(typ, "\t\r\n"); i != -1 {
+			typ, rest = typ[:i], typ[i:]+" "+rest
+		}
+	}
+
+	t, err := ParseType(typ)
+	if err != nil {
+		return nil, err
+	}
+	arg := &Argument{Type: t}
+
+	for _, word := range strings.Fields(rest) {
+		switch word {
+		case "indexed":
+			if !allowIndexed {
+				return nil, fmt.Errorf("%w: indexed not allowed here: %s", ErrInvalidType, s)
+			}
+			arg.Indexed = true
+		case "memory", "calldata", "storage":
+			// data location, irrelevant to the abi
+		case "payable":
+			if t.Kind != AddressKind {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidType, s)
+			}
+		default:
+			if arg.Name != "" {
+				return nil, fmt.Errorf("%w: unexpected %q in %s", ErrInvalidType, word, s)
+			}
+			arg.Name = word
+		}
+	}
+	return arg, nil
+}
+
+// splitTopLevel splits s on commas that are not within parentheses
+func splitTopLevel(s string) ([]string, error) {
+	var (
+		res   []string
+		depth int
+		start int
+	)
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth += 1
+		case ')':
+			depth -= 1
+			if depth < 0 {
+				return nil, fmt.Errorf("%w: unbalanced parenthesis in %s", ErrInvalidType, s)
+			}
+		case ',':
+			if depth == 0 {
+				res = append(res, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("%w: unbalanced parenthesis in %s", ErrInvalidType, s)
+	}
+	return append(res, s[start:]), nil
+}
+
+// matchParen returns the position of the parenthesis closing the one at position pos
+func matchParen(s string, pos int) (int, error) {
+	depth := 0
+	for i := pos; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth += 1
+		case ')':
+			depth -= 1
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("%w: unbalanced parenthesis in %s", ErrInvalidType, s)
+}
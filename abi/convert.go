@@ -0,0 +1,198 @@
+package abi
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+)
+
+// Copy stores values returned by Unpack into target, which must be a pointer. See
+// [Arguments.UnpackInto] for the accepted targets.
+func (args Arguments) Copy(target any, values []any) error {
+	dst := reflect.ValueOf(target)
+	if dst.Kind() != reflect.Pointer || dst.IsNil() {
+		return fmt.Errorf("%w: target must be a non-nil pointer", ErrInvalidValue)
+	}
+	dst = dst.Elem()
+
+	if len(args) == 1 && !isTupleTarget(dst, args) {
+		return assign(dst, values[0], args[0].Type)
+	}
+	return assign(dst, values, args.Types())
+}
+
+// CopyEach stores each of the values returned by Unpack into the matching pointer in targets,
+// which allows decoding values into separate variables or struct fields.
+func (args Arguments) CopyEach(values []any, targets ...any) error {
+	if len(values) != len(args) || len(targets) != len(args) {
+		return fmt.Errorf("%w: expected %d values and targets", ErrInvalidValue, len(args))
+	}
+	for i, arg := range args {
+		if err := arg.Type.ConvertType(targets[i], values[i]); err != nil {
+			if arg.Name != "" {
+				return fmt.Errorf("%s: %w", arg.Name, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// isTupleTarget returns true if dst should receive all the arguments at once, which is the
+// case for []any and structs, unless the single argument is itself a tuple
+func isTupleTarget(dst reflect.Value, args Arguments) bool {
+	switch dst.Kind() {
+	case reflect.Struct:
+		return dst.Type() != bigType.Elem() && args[0].Type.Kind != TupleKind
+	case reflect.Slice:
+		return dst.Type().Elem().Kind() == reflect.Interface && dst.Type().Elem().NumMethod() == 0
+	}
+	return false
+}
+
+// ConvertType stores a value as returned by [Type.Unpack] into target, which must be a pointer
+func (t *Type) ConvertType(target any, value any) error {
+	dst := reflect.ValueOf(target)
+	if dst.Kind() != reflect.Pointer || dst.IsNil() {
+		return fmt.Errorf("%w: target must be a non-nil pointer", ErrInvalidValue)
+	}
+	return assign(dst.Elem(), value, t)
+}
+
+// assign sets dst to the decoded value src of type t, converting as needed
+func assign(dst reflect.Value, src any, t *Type) error {
+	if dst.Kind() == reflect.Interface && dst.NumMethod() == 0 {
+		dst.Set(reflect.ValueOf(src))
+		return nil
+	}
+	if dst.Kind() == reflect.Pointer && dst.Type() != bigType {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return assign(dst.Elem(), src, t)
+	}
+
+	switch v := src.(type) {
+	case *big.Int:
+		return assignInt(dst, v)
+	case bool:
+		if dst.Kind() != reflect.Bool {
+			break
+		}
+		dst.SetBool(v)
+		return nil
+	case string:
+		if dst.Kind() != reflect.String {
+			break
+		}
+		dst.SetString(v)
+		return nil
+	case Address:
+		return assignBytes(dst, v[:], true)
+	case Hash:
+		return assignBytes(dst, v[:], true)
+	case []byte:
+		return assignBytes(dst, v, t.Kind != BytesKind)
+	case []any:
+		return assignList(dst, v, t)
+	}
+	return fmt.Errorf("%w: cannot store %s into %s", ErrInvalidValue, t, dst.Type())
+}
+
+func assignInt(dst reflect.Value, v *big.Int) error {
+	switch {
+	case dst.Type() == bigType:
+		dst.Set(reflect.ValueOf(new(big.Int).Set(v)))
+		return nil
+	case dst.Type() == bigType.Elem():
+		dst.Set(reflect.ValueOf(new(big.Int).Set(v)).Elem())
+		return nil
+	}
+	switch dst.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !v.IsInt64() || dst.OverflowInt(v.Int64()) {
+			return fmt.Errorf("%w: %s overflows %s", ErrInvalidValue, v, dst.Type())
+		}
+		dst.SetInt(v.Int64())
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if !v.IsUint64() || dst.OverflowUint(v.Uint64()) {
+			return fmt.Errorf("%w: %s overflows %s", ErrInvalidValue, v, dst.Type())
+		}
+		dst.SetUint(v.Uint64())
+		return nil
+	}
+	return fmt.Errorf("%w: cannot store integer into %s", ErrInvalidValue, dst.Type())
+}
+
+// assignBytes stores buf into a []byte or a byte array. If fixed is true, arrays must have
+// the exact same length as buf.
+func assignBytes(dst reflect.Value, buf []byte, fixed bool) error {
+	switch {
+	case dst.Kind() == reflect.Slice && dst.Type().Elem().Kind() == reflect.Uint8:
+		dst.SetBytes(append([]byte(nil), buf...))
+		return nil
+	case dst.Kind() == reflect.Array && dst.Type().Elem().Kind() == reflect.Uint8:
+		if dst.Len() != len(buf) && (fixed || dst.Len() < len(buf)) {
+			return fmt.Errorf("%w: cannot store %d bytes into %s", ErrInvalidValue, len(buf), dst.Type())
+		}
+		reflect.Copy(dst, reflect.ValueOf(buf))
+		return nil
+	case dst.Kind() == reflect.String:
+		dst.SetString(string(buf))
+		return nil
+	}
+	return fmt.Errorf("%w: cannot store bytes into %s", ErrInvalidValue, dst.Type())
+}
+
+// assignList stores a decoded array or tuple into a slice, array or struct
+func assignList(dst reflect.Value, list []any, t *Type) error {
+	elemType := func(i int) *Type {
+		if t.Kind == TupleKind {
+			return t.Components[i].Type
+		}
+		return t.Elem
+	}
+
+	switch dst.Kind() {
+	case reflect.Slice:
+		res := reflect.MakeSlice(dst.Type(), len(list), len(list))
+		for i, v := range list {
+			if err := assign(res.Index(i), v, elemType(i)); err != nil {
+				return err
+			}
+		}
+		dst.Set(res)
+		return nil
+	case reflect.Array:
+		if dst.Len() != len(list) {
+			return fmt.Errorf("%w: cannot store %d values into %s", ErrInvalidValue, len(list), dst.Type())
+		}
+		for i, v := range list {
+			if err := assign(dst.Index(i), v, elemType(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+		if t.Kind != TupleKind {
+			break
+		}
+		for i, c := range t.Components {
+			f, ok := structField(dst, c.Name)
+			if !ok {
+				if c.Name == "" && i < dst.NumField() && dst.Type().Field(i).IsExported() {
+					// unnamed values are matched by position
+					f = dst.Field(i)
+				} else {
+					continue
+				}
+			}
+			if err := assign(f, list[i], c.Type); err != nil {
+				return fmt.Errorf("%s: %w", c.Name, err)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: cannot store %s into %s", ErrInvalidValue, t, dst.Type())
+}
@@ -0,0 +1,203 @@
+package abi
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var ErrInvalidData = errors.New("invalid abi encoded data")
+
+// Unpack decodes data encoded according to the arguments, such as method return values.
+//
+// Values are returned as: *big.Int for all integer types, [Address] for addresses, bool,
+// string, []byte for bytes and bytesN, and []any for arrays and tuples.
+func (args Arguments) Unpack(data []byte) ([]any, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	v, err := args.Types().decode(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	return v.([]any), nil
+}
+
+// UnpackMap decodes data like Unpack, and returns the values in a map indexed by argument name.
+// Unnamed arguments use their position as name ("0", "1", ...).
+func (args Arguments) UnpackMap(data []byte) (map[string]any, error) {
+	values, err := args.Unpack(data)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]any, len(values))
+	for n, v := range values {
+		res[args.name(n)] = v
+	}
+	return res, nil
+}
+
+// UnpackInto decodes data and stores the result in target, which must be a pointer. If there is
+// a single argument, target can point to a value of the matching type. Otherwise target should
+// point to a struct with fields named after the arguments, or to a []any.
+func (args Arguments) UnpackInto(target any, data []byte) error {
+	values, err := args.Unpack(data)
+	if err != nil {
+		return err
+	}
+	return args.Copy(target, values)
+}
+
+// Unpack decodes a single value of this type
+func (t *Type) Unpack(data []byte) (any, error) {
+	return t.decode(data, 0)
+}
+
+func (args Arguments) name(n int) string {
+	if args[n].Name != "" {
+		return args[n].Name
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+// decode reads a value of type t at offset pos of data
+func (t *Type) decode(data []byte, pos int) (any, error) {
+	switch t.Kind {
+	case UintKind, IntKind:
+		word, err := readWord(data, pos)
+		if err != nil {
+			return nil, err
+		}
+		n := new(big.Int).SetBytes(word)
+		if t.Kind == IntKind && word[0]&0x80 != 0 {
+			n.Sub(n, tt256)
+		}
+		return n, nil
+	case AddressKind:
+		word, err := readWord(data, pos)
+		if err != nil {
+			return nil, err
+		}
+		var res Address
+		copy(res[:], word[12:])
+		return res, nil
+	case BoolKind:
+		word, err := readWord(data, pos)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range word[:31] {
+			if b != 0 {
+				return nil, fmt.Errorf("%w: invalid bool value", ErrInvalidData)
+			}
+		}
+		switch word[31] {
+		case 0:
+			return false, nil
+		case 1:
+			return true, nil
+		}
+		return nil, fmt.Errorf("%w: invalid bool value", ErrInvalidData)
+	case FixedBytesKind, FunctionKind:
+		word, err := readWord(data, pos)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), word[:t.Size]...), nil
+	case StringKind, BytesKind:
+		ln, err := readLength(data, pos)
+		if err != nil {
+			return nil, err
+		}
+		start := pos + 32
+		if ln > len(data)-start {
+			return nil, fmt.Errorf("%w: %s length %d exceeds data", ErrInvalidData, t, ln)
+		}
+		buf := data[start : start+ln]
+		if t.Kind == StringKind {
+			return string(buf), nil
+		}
+		return append([]byte(nil), buf...), nil
+	case SliceKind:
+		ln, err := readLength(data, pos)
+		if err != nil {
+			return nil, err
+		}
+		// each element uses at least one word, this avoids huge allocations on bad data
+		if ln > (len(data)-pos-32)/32 {
+			return nil, fmt.Errorf("%w: %s length %d exceeds data", ErrInvalidData, t, ln)
+		}
+		types := make([]*Type, ln)
+		for i := range types {
+			types[i] = t.Elem
+		}
+		return decodeSequence(types, data[pos+32:])
+	case ArrayKind:
+		types := make([]*Type, t.Size)
+		for i := range types {
+			types[i] = t.Elem
+		}
+		return decodeSequence(types, data[pos:])
+	case TupleKind:
+		types := make([]*Type, len(t.Components))
+		for i, c := range t.Components {
+			types[i] = c.Type
+		}
+		return decodeSequence(types, data[pos:])
+	}
+	return nil, fmt.Errorf("%w: unsupported type %s", ErrInvalidType, t)
+}
+
+// decodeSequence decodes a list of values encoded as a head and a tail, offsets of dynamic
+// values being relative to the start of data
+func decodeSequence(types []*Type, data []byte) ([]any, error) {
+	res := make([]any, len(types))
+	pos := 0
+	for i, t := range types {
+		if t.IsDynamic() {
+			offset, err := readLength(data, pos)
+			if err != nil {
+				return nil, err
+			}
+			if offset > len(data) {
+				return nil, fmt.Errorf("%w: offset %d out of bounds", ErrInvalidData, offset)
+			}
+			res[i], err = t.decode(data, offset)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			if pos > len(data) {
+				return nil, fmt.Errorf("%w: data too short", ErrInvalidData)
+			}
+			var err error
+			res[i], err = t.decode(data, pos)
+			if err != nil {
+				return nil, err
+			}
+		}
+		pos += t.headSize()
+	}
+	return res, nil
+}
+
+// readWord returns the 32 bytes word at pos
+func readWord(data []byte, pos int) ([]byte, error) {
+	if pos < 0 || len(data)-pos < 32 {
+		return nil, fmt.Errorf("%w: data too short", ErrInvalidData)
+	}
+	return data[pos : pos+32], nil
+}
+
+// readLength reads a word at pos that is used as a length or offset and must fit in an int
+func readLength(data []byte, pos int) (int, error) {
+	word, err := readWord(data, pos)
+	if err != nil {
+		return 0, err
+	}
+	n := new(big.Int).SetBytes(word)
+	if !n.IsInt64() || n.Int64() > int64(len(data)) {
+		return 0, fmt.Errorf("%w: length or offset %s out of bounds", ErrInvalidData, n)
+	}
+	return int(n.Int64()), nil
+}
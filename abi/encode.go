@@ -0,0 +1,325 @@
+package abi
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrInvalidValue = errors.New("invalid value for abi type")
+
+	tt256   = new(big.Int).Lsh(big.NewInt(1), 256)
+	bigType = reflect.TypeOf((*big.Int)(nil))
+)
+
+// Pack encodes the given values according to the arguments, as done for method parameters
+// and return values.
+//
+// Integers can be passed as any go integer type, *big.Int or a decimal/0x-prefixed string.
+// Addresses can be an [Address], a [20]byte, or a hex string. Bytes can be []byte, fixed size
+// byte arrays or 0x-prefixed hex strings. Tuples can be passed as []any, structs or
+// map[string]any, with fields matched by name.
+func (args Arguments) Pack(values ...any) ([]byte, error) {
+	if len(values) != len(args) {
+		return nil, fmt.Errorf("%w: expected %d values, got %d", ErrInvalidValue, len(args), len(values))
+	}
+	return args.Types().encode(reflect.ValueOf(values))
+}
+
+// Pack encodes a single value of this type
+func (t *Type) Pack(v any) ([]byte, error) {
+	return t.encode(reflect.ValueOf(v))
+}
+
+func (t *Type) encode(v reflect.Value) ([]byte, error) {
+	v = indirect(v)
+
+	switch t.Kind {
+	case UintKind, IntKind:
+		n, err := toBigInt(v)
+		if err != nil {
+			return nil, err
+		}
+		return encodeInt(t, n)
+	case AddressKind:
+		a, err := toAddress(v)
+		if err != nil {
+			return nil, err
+		}
+		return leftPad(a[:]), nil
+	case BoolKind:
+		if v.Kind() != reflect.Bool {
+			return nil, fmt.Errorf("%w: expected bool, got %s", ErrInvalidValue, typeName(v))
+		}
+		res := make([]byte, 32)
+		if v.Bool() {
+			res[31] = 1
+		}
+		return res, nil
+	case StringKind, BytesKind:
+		var buf []byte
+		if t.Kind == StringKind && v.Kind() == reflect.String {
+			buf = []byte(v.String())
+		} else {
+			var err error
+			buf, err = toBytes(v)
+			if err != nil {
+				return nil, err
+			}
+		}
+		res := leftPad(big.NewInt(int64(len(buf))).Bytes())
+		return append(res, rightPad(buf)...), nil
+	case FixedBytesKind, FunctionKind:
+		buf, err := toBytes(v)
+		if err != nil {
+			return nil, err
+		}
+		if len(buf) != t.Size {
+			return nil, fmt.Errorf("%w: %s requires %d bytes, got %d", ErrInvalidValue, t, t.Size, len(buf))
+		}
+		return rightPad(buf), nil
+	case SliceKind, ArrayKind:
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return nil, fmt.Errorf("%w: expected array for %s, got %s", ErrInvalidValue, t, typeName(v))
+		}
+		if t.Kind == ArrayKind && v.Len() != t.Size {
+			return nil, fmt.Errorf("%w: %s requires %d elements, got %d", ErrInvalidValue, t, t.Size, v.Len())
+		}
+		types := make([]*Type, v.Len())
+		values := make([]reflect.Value, v.Len())
+		for i := range values {
+			types[i] = t.Elem
+			values[i] = v.Index(i)
+		}
+		res, err := encodeSequence(types, values)
+		if err != nil {
+			return nil, err
+		}
+		if t.Kind == SliceKind {
+			res = append(leftPad(big.NewInt(int64(v.Len())).Bytes()), res...)
+		}
+		return res, nil
+	case TupleKind:
+		values, err := tupleValues(t, v)
+		if err != nil {
+			return nil, err
+		}
+		types := make([]*Type, len(t.Components))
+		for i, c := range t.Components {
+			types[i] = c.Type
+		}
+		return encodeSequence(types, values)
+	}
+	return nil, fmt.Errorf("%w: unsupported type %s", ErrInvalidType, t)
+}
+
+// encodeSequence encodes a list of values as a head followed by the dynamic tail
+func encodeSequence(types []*Type, values []reflect.Value) ([]byte, error) {
+	headLen := 0
+	for _, t := range types {
+		headLen += t.headSize()
+	}
+
+	var head, tail []byte
+	for i, t := range types {
+		enc, err := t.encode(values[i])
+		if err != nil {
+			return nil, err
+		}
+		if t.IsDynamic() {
+			head = append(head, leftPad(big.NewInt(int64(headLen+len(tail))).Bytes())...)
+			tail = append(tail, enc...)
+		} else {
+			head = append(head, enc...)
+		}
+	}
+	return append(head, tail...), nil
+}
+
+// tupleValues returns the values matching the components of a tuple type, from a slice,
+// a struct or a map
+func tupleValues(t *Type, v reflect.Value) ([]reflect.Value, error) {
+	res := make([]reflect.Value, len(t.Components))
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Len() != len(t.Components) {
+			return nil, fmt.Errorf("%w: %s requires %d values, got %d", ErrInvalidValue, t, len(t.Components), v.Len())
+		}
+		for i := range res {
+			res[i] = v.Index(i)
+		}
+	case reflect.Struct:
+		for i, c := range t.Components {
+			f, ok := structField(v, c.Name)
+			if !ok {
+				if c.Name != "" || i >= v.NumField() {
+					return nil, fmt.Errorf("%w: no field matching %q in %s", ErrInvalidValue, c.Name, v.Type())
+				}
+				// unnamed values are matched by position
+				f = v.Field(i)
+			}
+			res[i] = f
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("%w: map keys must be strings", ErrInvalidValue)
+		}
+		for i, c := range t.Components {
+			f := v.MapIndex(reflect.ValueOf(c.Name).Convert(v.Type().Key()))
+			if !f.IsValid() {
+				return nil, fmt.Errorf("%w: missing value for %q", ErrInvalidValue, c.Name)
+			}
+			res[i] = f
+		}
+	default:
+		return nil, fmt.Errorf("%w: expected tuple for %s, got %s", ErrInvalidValue, t, typeName(v))
+	}
+	return res, nil
+}
+
+// structField finds the field of a struct matching the given abi argument name, either through
+// an `abi:"name"` tag, or by comparing names case-insensitively and ignoring underscores
+func structField(v reflect.Value, name string) (reflect.Value, bool) {
+	typ := v.Type()
+	norm := normalizeName(name)
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if tag, ok := f.Tag.Lookup("abi"); ok {
+			if tag == name {
+				return v.Field(i), true
+			}
+			continue
+		}
+		if normalizeName(f.Name) == norm {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func normalizeName(s string) string {
+	return strings.ToLower(strings.ReplaceAll(s, "_", ""))
+}
+
+// encodeInt encodes n as a 32 bytes two's complement value after checking it fits in t
+func encodeInt(t *Type, n *big.Int) ([]byte, error) {
+	if t.Kind == UintKind {
+		if n.Sign() < 0 || n.BitLen() > t.Size {
+			return nil, fmt.Errorf("%w: %s overflows %s", ErrInvalidValue, n, t)
+		}
+		return leftPad(n.Bytes()), nil
+	}
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(t.Size-1))
+	if n.Cmp(limit) >= 0 || n.Cmp(new(big.Int).Neg(limit)) < 0 {
+		return nil, fmt.Errorf("%w: %s overflows %s", ErrInvalidValue, n, t)
+	}
+	if n.Sign() < 0 {
+		n = new(big.Int).Add(n, tt256)
+	}
+	return leftPad(n.Bytes()), nil
+}
+
+func toBigInt(v reflect.Value) (*big.Int, error) {
+	if !v.IsValid() {
+		return nil, fmt.Errorf("%w: expected integer, got nil", ErrInvalidValue)
+	}
+	if v.Type() == bigType.Elem() {
+		n := v.Interface().(big.Int)
+		return new(big.Int).Set(&n), nil
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return new(big.Int).SetUint64(v.Uint()), nil
+	case reflect.String:
+		res, ok := new(big.Int).SetString(v.String(), 0)
+		if !ok {
+			return nil, fmt.Errorf("%w: invalid integer %q", ErrInvalidValue, v.String())
+		}
+		return res, nil
+	}
+	return nil, fmt.Errorf("%w: expected integer, got %s", ErrInvalidValue, typeName(v))
+}
+
+func toAddress(v reflect.Value) (Address, error) {
+	var res Address
+	switch {
+	case v.Kind() == reflect.String:
+		return ParseAddress(v.String())
+	case v.Kind() == reflect.Array && v.Len() == 20 && v.Type().Elem().Kind() == reflect.Uint8:
+		reflect.Copy(reflect.ValueOf(res[:]), v)
+		return res, nil
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		if v.Len() != 20 {
+			return res, fmt.Errorf("%w: address must be 20 bytes, got %d", ErrInvalidValue, v.Len())
+		}
+		copy(res[:], v.Bytes())
+		return res, nil
+	}
+	return res, fmt.Errorf("%w: expected address, got %s", ErrInvalidValue, typeName(v))
+}
+
+func toBytes(v reflect.Value) ([]byte, error) {
+	switch {
+	case v.Kind() == reflect.String:
+		s := v.String()
+		if !strings.HasPrefix(s, "0x") && !strings.HasPrefix(s, "0X") {
+			return nil, fmt.Errorf("%w: bytes strings must be 0x-prefixed hex", ErrInvalidValue)
+		}
+		res, err := hex.DecodeString(s[2:])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidValue, err)
+		}
+		return res, nil
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		return v.Bytes(), nil
+	case v.Kind() == reflect.Array && v.Type().Elem().Kind() == reflect.Uint8:
+		res := make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(res), v)
+		return res, nil
+	}
+	return nil, fmt.Errorf("%w: expected bytes, got %s", ErrInvalidValue, typeName(v))
+}
+
+// indirect follows pointers and interfaces until it reaches a value
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer) && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
+
+func typeName(v reflect.Value) string {
+	if !v.IsValid() {
+		return "nil"
+	}
+	return v.Type().String()
+}
+
+// leftPad pads buf with zeroes on the left to a 32 bytes word
+func leftPad(buf []byte) []byte {
+	if len(buf) >= 32 {
+		return buf
+	}
+	res := make([]byte, 32)
+	copy(res[32-len(buf):], buf)
+	return res
+}
+
+// rightPad pads buf with zeroes on the right to a multiple of 32 bytes
+func rightPad(buf []byte) []byte {
+	if len(buf)%32 == 0 {
+		return buf
+	}
+	res := make([]byte, (len(buf)+31)/32*32)
+	copy(res, buf)
+	return res
+}
@@ -0,0 +1,71 @@
+package abi
+
+import "fmt"
+
+// DecodeLog decodes the topics and data of a log emitted by this event. Values are returned
+// in the order of the event inputs, the same way as [Arguments.Unpack].
+//
+// Indexed parameters of dynamic types (strings, bytes, arrays and tuples) are only stored as
+// the hash of their value in the log, and are returned as a [Hash].
+func (e *Event) DecodeLog(topics []Hash, data []byte) ([]any, error) {
+	if !e.Anonymous {
+		if len(topics) == 0 || topics[0] != e.ID() {
+			return nil, fmt.Errorf("%w: log does not match event %s", ErrInvalidData, e.Sig())
+		}
+		topics = topics[1:]
+	}
+
+	values, err := e.Inputs.NonIndexed().Unpack(data)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]any, len(e.Inputs))
+	for i, arg := range e.Inputs {
+		if !arg.Indexed {
+			res[i], values = values[0], values[1:]
+			continue
+		}
+		if len(topics) == 0 {
+			return nil, fmt.Errorf("%w: not enough topics for event %s", ErrInvalidData, e.Sig())
+		}
+		topic := topics[0]
+		topics = topics[1:]
+		if arg.Type.IsHashedTopic() {
+			res[i] = topic
+			continue
+		}
+		res[i], err = arg.Type.Unpack(topic[:])
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(topics) != 0 {
+		return nil, fmt.Errorf("%w: too many topics for event %s", ErrInvalidData, e.Sig())
+	}
+	return res, nil
+}
+
+// DecodeLogInto decodes a log like DecodeLog and stores the values into target, see
+// [Arguments.UnpackInto] for the accepted targets. Fields receiving hashed indexed parameters
+// should be of type [Hash] or [32]byte.
+func (e *Event) DecodeLogInto(target any, topics []Hash, data []byte) error {
+	values, err := e.DecodeLog(topics, data)
+	if err != nil {
+		return err
+	}
+	return e.Inputs.Copy(target, values)
+}
+
+// DecodeLogMap decodes a log like DecodeLog and returns the values indexed by name
+func (e *Event) DecodeLogMap(topics []Hash, data []byte) (map[string]any, error) {
+	values, err := e.DecodeLog(topics, data)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]any, len(values))
+	for n, v := range values {
+		res[e.Inputs.name(n)] = v
+	}
+	return res, nil
+}
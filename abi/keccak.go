@@ -0,0 +1,23 @@
+package abi
+
+import "golang.org/x/crypto/sha3"
+
+// Keccak256 returns the Keccak-256 hash of the concatenation of the given buffers, as used by
+// Ethereum (this is not the same as the standardized SHA3-256)
+func Keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, b := range data {
+		h.Write(b)
+	}
+	return h.Sum(nil)
+}
+
+// Keccak256Hash is the same as Keccak256 but returns a [Hash]
+func Keccak256Hash(data ...[]byte) (res Hash) {
+	h := sha3.NewLegacyKeccak256()
+	for _, b := range data {
+		h.Write(b)
+	}
+	h.Sum(res[:0])
+	return
+}
@@ -0,0 +1,139 @@
+package abi
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Method is a contract function, constructor, fallback or receive function
+type Method struct {
+	Name            string
+	Type            string // function, constructor, fallback or receive
+	Inputs          Arguments
+	Outputs         Arguments
+	StateMutability string // pure, view, nonpayable or payable
+}
+
+// Sig returns the canonical signature of the method, such as "transfer(address,uint256)"
+func (m *Method) Sig() string {
+	return m.Name + "(" + m.Inputs.typeList() + ")"
+}
+
+// Selector returns the 4 bytes selector of the method
+func (m *Method) Selector() []byte {
+	return Keccak256([]byte(m.Sig()))[:4]
+}
+
+// IsConstant returns true if the method does not modify the state (view or pure)
+func (m *Method) IsConstant() bool {
+	return m.StateMutability == "view" || m.StateMutability == "pure"
+}
+
+// IsPayable returns true if the method accepts ether
+func (m *Method) IsPayable() bool {
+	return m.StateMutability == "payable"
+}
+
+// Pack returns the calldata for a call to this method with the given arguments. For
+// constructors, only the encoded arguments are returned and must be appended to the bytecode.
+func (m *Method) Pack(args ...any) ([]byte, error) {
+	enc, err := m.Inputs.Pack(args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", m.Name, err)
+	}
+	if m.Type == "constructor" {
+		return enc, nil
+	}
+	return append(m.Selector(), enc...), nil
+}
+
+// UnpackInputs decodes calldata for this method, including the selector
+func (m *Method) UnpackInputs(data []byte) ([]any, error) {
+	if len(data) < 4 || !bytes.Equal(data[:4], m.Selector()) {
+		return nil, fmt.Errorf("%w: selector does not match %s", ErrInvalidData, m.Sig())
+	}
+	return m.Inputs.Unpack(data[4:])
+}
+
+// Unpack decodes the values returned by this method
+func (m *Method) Unpack(data []byte) ([]any, error) {
+	return m.Outputs.Unpack(data)
+}
+
+// UnpackInto decodes the values returned by this method into target
+func (m *Method) UnpackInto(target any, data []byte) error {
+	return m.Outputs.UnpackInto(target, data)
+}
+
+// String returns the method in human-readable format
+func (m *Method) String() string {
+	var res string
+	switch m.Type {
+	case "constructor", "fallback", "receive":
+		res = m.Type + "(" + m.Inputs.String() + ")"
+	default:
+		res = "function " + m.Name + "(" + m.Inputs.String() + ")"
+	}
+	if m.StateMutability != "" && m.StateMutability != "nonpayable" {
+		res += " " + m.StateMutability
+	}
+	if len(m.Outputs) > 0 {
+		res += " returns (" + m.Outputs.String() + ")"
+	}
+	return res
+}
+
+// Event is a contract event
+type Event struct {
+	Name      string
+	Inputs    Arguments
+	Anonymous bool
+}
+
+// Sig returns the canonical signature of the event, such as "Transfer(address,address,uint256)"
+func (e *Event) Sig() string {
+	return e.Name + "(" + e.Inputs.typeList() + ")"
+}
+
+// ID returns the hash of the event signature, which is used as first topic of the logs
+func (e *Event) ID() Hash {
+	return Keccak256Hash([]byte(e.Sig()))
+}
+
+// String returns the event in human-readable format
+func (e *Event) String() string {
+	res := "event " + e.Name + "(" + e.Inputs.String() + ")"
+	if e.Anonymous {
+		res += " anonymous"
+	}
+	return res
+}
+
+// Error is a custom error that can be returned by a contract when reverting
+type Error struct {
+	Name   string
+	Inputs Arguments
+}
+
+// Sig returns the canonical signature of the error
+func (e *Error) Sig() string {
+	return e.Name + "(" + e.Inputs.typeList() + ")"
+}
+
+// Selector returns the 4 bytes selector of the error
+func (e *Error) Selector() []byte {
+	return Keccak256([]byte(e.Sig()))[:4]
+}
+
+// Unpack decodes revert data for this error, including the selector
+func (e *Error) Unpack(data []byte) ([]any, error) {
+	if len(data) < 4 || !bytes.Equal(data[:4], e.Selector()) {
+		return nil, fmt.Errorf("%w: selector does not match %s", ErrInvalidData, e.Sig())
+	}
+	return e.Inputs.Unpack(data[4:])
+}
+
+// String returns the error in human-readable format
+func (e *Error) String() string {
+	return "error " + e.Name + "(" + e.Inputs.String() + ")"
+}
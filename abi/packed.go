@@ -0,0 +1,91 @@
+package abi
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// EncodePacked encodes the values using the non-standard packed mode of solidity's
+// abi.encodePacked, with types given as strings such as "address" or "uint8".
+//
+// Values are encoded with their minimal size and no padding, except for array elements which
+// are padded to 32 bytes. Tuples and arrays of dynamic types are not supported.
+func EncodePacked(types []string, values ...any) ([]byte, error) {
+	if len(types) != len(values) {
+		return nil, fmt.Errorf("%w: expected %d values, got %d", ErrInvalidValue, len(types), len(values))
+	}
+	var res []byte
+	for i, s := range types {
+		t, err := ParseType(s)
+		if err != nil {
+			return nil, err
+		}
+		enc, err := t.PackPacked(values[i])
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, enc...)
+	}
+	return res, nil
+}
+
+// PackPacked encodes a single value using the packed mode
+func (t *Type) PackPacked(v any) ([]byte, error) {
+	return t.encodePacked(reflect.ValueOf(v), false)
+}
+
+func (t *Type) encodePacked(v reflect.Value, inArray bool) ([]byte, error) {
+	switch t.Kind {
+	case StringKind, BytesKind:
+		if inArray {
+			return nil, fmt.Errorf("%w: packed encoding of %s in arrays is not supported", ErrInvalidType, t)
+		}
+		v = indirect(v)
+		if v.Kind() == reflect.String && t.Kind == StringKind {
+			return []byte(v.String()), nil
+		}
+		return toBytes(v)
+	case SliceKind, ArrayKind:
+		if inArray {
+			return nil, fmt.Errorf("%w: packed encoding of nested arrays is not supported", ErrInvalidType)
+		}
+		v = indirect(v)
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return nil, fmt.Errorf("%w: expected array for %s, got %s", ErrInvalidValue, t, typeName(v))
+		}
+		if t.Kind == ArrayKind && v.Len() != t.Size {
+			return nil, fmt.Errorf("%w: %s requires %d elements, got %d", ErrInvalidValue, t, t.Size, v.Len())
+		}
+		var res []byte
+		for i := 0; i < v.Len(); i++ {
+			enc, err := t.Elem.encodePacked(v.Index(i), true)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, enc...)
+		}
+		return res, nil
+	case TupleKind:
+		return nil, fmt.Errorf("%w: packed encoding of tuples is not supported", ErrInvalidType)
+	}
+
+	enc, err := t.encode(v)
+	if err != nil {
+		return nil, err
+	}
+	if inArray {
+		// array elements keep their full 32 bytes
+		return enc, nil
+	}
+
+	switch t.Kind {
+	case UintKind, IntKind:
+		return enc[32-t.Size/8:], nil
+	case AddressKind:
+		return enc[12:], nil
+	case BoolKind:
+		return enc[31:], nil
+	default: // FixedBytesKind, FunctionKind
+		return enc[:t.Size], nil
+	}
+}
@@ -0,0 +1,90 @@
+package abi
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// OneOf is a set of alternative values for an indexed event parameter when building topic
+// filters, any of the values will match
+type OneOf []any
+
+// Topics returns the topic filter matching this event with the given values for its indexed
+// parameters, in order. A nil value matches anything, and [OneOf] can be used to match any of a
+// set of values. The first topic is the event ID, unless the event is anonymous.
+//
+// Values of indexed strings and bytes are hashed, values for other dynamic types must be given
+// as a precomputed [Hash].
+func (e *Event) Topics(values ...any) ([][]Hash, error) {
+	var indexed Arguments
+	for _, arg := range e.Inputs {
+		if arg.Indexed {
+			indexed = append(indexed, arg)
+		}
+	}
+	if len(values) > len(indexed) {
+		return nil, fmt.Errorf("%w: event %s has %d indexed parameters", ErrInvalidValue, e.Sig(), len(indexed))
+	}
+
+	var res [][]Hash
+	if !e.Anonymous {
+		res = append(res, []Hash{e.ID()})
+	}
+	for i, v := range values {
+		if v == nil {
+			res = append(res, nil)
+			continue
+		}
+		alts, ok := v.(OneOf)
+		if !ok {
+			alts = OneOf{v}
+		}
+		var pos []Hash
+		for _, alt := range alts {
+			h, err := indexed[i].Type.Topic(alt)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", indexed[i].Name, err)
+			}
+			pos = append(pos, h)
+		}
+		res = append(res, pos)
+	}
+
+	// trailing wildcards are not needed
+	for len(res) > 0 && res[len(res)-1] == nil {
+		res = res[:len(res)-1]
+	}
+	return res, nil
+}
+
+// Topic returns the topic value of v when used as an indexed event parameter of this type
+func (t *Type) Topic(v any) (Hash, error) {
+	if h, ok := v.(Hash); ok {
+		return h, nil
+	}
+
+	var res Hash
+	switch t.Kind {
+	case StringKind, BytesKind:
+		rv := indirect(reflect.ValueOf(v))
+		var buf []byte
+		if t.Kind == StringKind && rv.Kind() == reflect.String {
+			buf = []byte(rv.String())
+		} else {
+			var err error
+			if buf, err = toBytes(rv); err != nil {
+				return res, err
+			}
+		}
+		return Keccak256Hash(buf), nil
+	case SliceKind, ArrayKind, TupleKind:
+		return res, fmt.Errorf("%w: topics for %s must be given as a hash", ErrInvalidValue, t)
+	}
+
+	enc, err := t.Pack(v)
+	if err != nil {
+		return res, err
+	}
+	copy(res[:], enc)
+	return res, nil
+}
@@ -0,0 +1,208 @@
+package abi
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kind is the kind of a solidity type
+type Kind int
+
+const (
+	UintKind Kind = iota
+	IntKind
+	AddressKind
+	BoolKind
+	StringKind
+	BytesKind      // dynamic bytes
+	FixedBytesKind // bytes1 to bytes32
+	FunctionKind   // 24 bytes, address + selector
+	SliceKind      // T[]
+	ArrayKind      // T[k]
+	TupleKind
+)
+
+var ErrInvalidType = errors.New("invalid abi type")
+
+// Type is a parsed solidity type
+type Type struct {
+	Kind       Kind
+	Size       int       // bits for int/uint, bytes for bytesN, length for T[k]
+	Elem       *Type     // element type for T[] and T[k]
+	Components Arguments // tuple components
+}
+
+// ParseType parses a solidity type such as "uint256", "bytes32[]" or "(address,uint256)[2]"
+func ParseType(s string) (*Type, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, fmt.Errorf("%w: empty type", ErrInvalidType)
+	}
+
+	// array suffixes
+	if strings.HasSuffix(s, "]") {
+		pos := strings.LastIndexByte(s, '[')
+		if pos == -1 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidType, s)
+		}
+		elem, err := ParseType(s[:pos])
+		if err != nil {
+			return nil, err
+		}
+		ln := s[pos+1 : len(s)-1]
+		if ln == "" {
+			return &Type{Kind: SliceKind, Elem: elem}, nil
+		}
+		n, err := strconv.Atoi(ln)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("%w: invalid array length in %s", ErrInvalidType, s)
+		}
+		return &Type{Kind: ArrayKind, Size: n, Elem: elem}, nil
+	}
+
+	// tuples
+	if strings.HasPrefix(s, "tuple(") {
+		s = s[5:]
+	}
+	if strings.HasPrefix(s, "(") {
+		if !strings.HasSuffix(s, ")") {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidType, s)
+		}
+		comps, err := parseParams(s[1:len(s)-1], false)
+		if err != nil {
+			return nil, err
+		}
+		return &Type{Kind: TupleKind, Components: comps}, nil
+	}
+
+	switch s {
+	case "address":
+		return &Type{Kind: AddressKind, Size: 20}, nil
+	case "bool":
+		return &Type{Kind: BoolKind}, nil
+	case "string":
+		return &Type{Kind: StringKind}, nil
+	case "bytes":
+		return &Type{Kind: BytesKind}, nil
+	case "function":
+		return &Type{Kind: FunctionKind, Size: 24}, nil
+	case "uint":
+		return &Type{Kind: UintKind, Size: 256}, nil
+	case "int":
+		return &Type{Kind: IntKind, Size: 256}, nil
+	}
+
+	var (
+		kind Kind
+		rest string
+	)
+	switch {
+	case strings.HasPrefix(s, "uint"):
+		kind, rest = UintKind, s[4:]
+	case strings.HasPrefix(s, "int"):
+		kind, rest = IntKind, s[3:]
+	case strings.HasPrefix(s, "bytes"):
+		kind, rest = FixedBytesKind, s[5:]
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidType, s)
+	}
+
+	n, err := strconv.Atoi(rest)
+	if err != nil || rest[0] == '0' {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidType, s)
+	}
+	if kind == FixedBytesKind {
+		if n < 1 || n > 32 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidType, s)
+		}
+	} else if n < 8 || n > 256 || n%8 != 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidType, s)
+	}
+	return &Type{Kind: kind, Size: n}, nil
+}
+
+// MustParseType is the same as ParseType but panics on error
+func MustParseType(s string) *Type {
+	t, err := ParseType(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// String returns the canonical representation of the type, as used to compute selectors
+func (t *Type) String() string {
+	switch t.Kind {
+	case UintKind:
+		return "uint" + strconv.Itoa(t.Size)
+	case IntKind:
+		return "int" + strconv.Itoa(t.Size)
+	case AddressKind:
+		return "address"
+	case BoolKind:
+		return "bool"
+	case StringKind:
+		return "string"
+	case BytesKind:
+		return "bytes"
+	case FixedBytesKind:
+		return "bytes" + strconv.Itoa(t.Size)
+	case FunctionKind:
+		return "function"
+	case SliceKind:
+		return t.Elem.String() + "[]"
+	case ArrayKind:
+		return t.Elem.String() + "[" + strconv.Itoa(t.Size) + "]"
+	case TupleKind:
+		return "(" + t.Components.typeList() + ")"
+	default:
+		return "invalid"
+	}
+}
+
+// IsDynamic returns true if the encoded size of values of this type depends on the value
+func (t *Type) IsDynamic() bool {
+	switch t.Kind {
+	case StringKind, BytesKind, SliceKind:
+		return true
+	case ArrayKind:
+		return t.Elem.IsDynamic()
+	case TupleKind:
+		for _, c := range t.Components {
+			if c.Type.IsDynamic() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsHashedTopic returns true if values of this type are stored as a hash when used as an
+// indexed event parameter
+func (t *Type) IsHashedTopic() bool {
+	switch t.Kind {
+	case StringKind, BytesKind, SliceKind, ArrayKind, TupleKind:
+		return true
+	}
+	return false
+}
+
+// headSize returns the number of bytes used by this type in the head part of an encoding
+func (t *Type) headSize() int {
+	if t.IsDynamic() {
+		return 32
+	}
+	switch t.Kind {
+	case ArrayKind:
+		return t.Size * t.Elem.headSize()
+	case TupleKind:
+		n := 0
+		for _, c := range t.Components {
+			n += c.Type.headSize()
+		}
+		return n
+	}
+	return 32
+}
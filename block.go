@@ -0,0 +1,82 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+
+	"github.com/ModChain/ethrpc/abi"
+)
+
+// BlockHeader holds the main fields of a block header
+type BlockHeader struct {
+	Number        uint64
+	Hash          abi.Hash
+	ParentHash    abi.Hash
+	Timestamp     uint64
+	GasLimit      uint64
+	GasUsed       uint64
+	BaseFeePerGas *big.Int // nil before EIP-1559
+}
+
+type blockHeaderJSON struct {
+	Number        string   `json:"number"`
+	Hash          abi.Hash `json:"hash"`
+	ParentHash    abi.Hash `json:"parentHash"`
+	Timestamp     string   `json:"timestamp"`
+	GasLimit      string   `json:"gasLimit"`
+	GasUsed       string   `json:"gasUsed"`
+	BaseFeePerGas string   `json:"baseFeePerGas,omitempty"`
+}
+
+func (h *BlockHeader) UnmarshalJSON(buf []byte) error {
+	var v *blockHeaderJSON
+	err := json.Unmarshal(buf, &v)
+	if err != nil || v == nil {
+		return err
+	}
+	*h = BlockHeader{Hash: v.Hash, ParentHash: v.ParentHash}
+	if h.Number, err = parseQuantity(v.Number); err != nil {
+		return err
+	}
+	if h.Timestamp, err = parseQuantity(v.Timestamp); err != nil {
+		return err
+	}
+	if h.GasLimit, err = parseQuantity(v.GasLimit); err != nil {
+		return err
+	}
+	if h.GasUsed, err = parseQuantity(v.GasUsed); err != nil {
+		return err
+	}
+	if v.BaseFeePerGas != "" {
+		var ok bool
+		h.BaseFeePerGas, ok = new(big.Int).SetString(v.BaseFeePerGas, 0)
+		if !ok {
+			return ErrInvalidResponse
+		}
+	}
+	return nil
+}
+
+func (h *BlockHeader) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&blockHeaderJSON{
+		Number:        hexUint(h.Number),
+		Hash:          h.Hash,
+		ParentHash:    h.ParentHash,
+		Timestamp:     hexUint(h.Timestamp),
+		GasLimit:      hexUint(h.GasLimit),
+		GasUsed:       hexUint(h.GasUsed),
+		BaseFeePerGas: hexBig(h.BaseFeePerGas),
+	})
+}
+
+// HeaderByNumber returns the header of the given block, which can be a number (see BlockTag)
+// or a tag such as "latest". A nil header is returned if the block does not exist.
+func (a *Api) HeaderByNumber(ctx context.Context, block string) (*BlockHeader, error) {
+	return ReadAs[*BlockHeader](a.Handler.DoCtx(ctx, "eth_getBlockByNumber", block, false))
+}
+
+// HeaderByHash returns the header of the block with the given hash, or nil if not found
+func (a *Api) HeaderByHash(ctx context.Context, hash abi.Hash) (*BlockHeader, error) {
+	return ReadAs[*BlockHeader](a.Handler.DoCtx(ctx, "eth_getBlockByHash", hash, false))
+}
@@ -0,0 +1,119 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// BroadcastResult is the outcome of sending a transaction to a single endpoint
+type BroadcastResult struct {
+	Handler Handler
+	Hash    string // transaction hash as returned by the endpoint
+	Known   bool   // true if the endpoint reported it already knew the transaction
+	Err     error
+}
+
+// Broadcast sends a raw signed transaction to all the servers in the list and to any extra
+// handler passed (such as private relays) simultaneously, and returns as soon as one of them
+// accepts it.
+//
+// Endpoints reporting the transaction as already known are considered successful. The returned
+// hash is the first one accepted by any endpoint, and an error is returned only if no endpoint
+// accepted the transaction. The outcome of each endpoint is sent to the returned channel, which
+// is closed once all of them answered. Endpoints still pending when Broadcast returns keep using
+// ctx, so cancelling it aborts them.
+func (r RPCList) Broadcast(ctx context.Context, rawTx string, extra ...Handler) (string, <-chan *BroadcastResult, error) {
+	handlers := make([]Handler, 0, len(r)+len(extra))
+	for _, h := range r {
+		handlers = append(handlers, h)
+	}
+	handlers = append(handlers, extra...)
+	if len(handlers) == 0 {
+		return "", nil, ErrNoAvailableServer
+	}
+
+	done := make(chan *BroadcastResult, len(handlers))
+	results := make(chan *BroadcastResult, len(handlers))
+
+	for _, h := range handlers {
+		go func(h Handler) {
+			res := &BroadcastResult{Handler: h}
+			res.Hash, res.Err = ReadString(h.DoCtx(ctx, "eth_sendRawTransaction", rawTx))
+			if res.Err != nil && isAlreadyKnown(res.Err) {
+				res.Known = true
+				res.Err = nil
+			}
+			done <- res
+		}(h)
+	}
+
+	var lastErr error
+	for n := range handlers {
+		res := <-done
+		results <- res
+		if res.Err != nil {
+			lastErr = res.Err
+			continue
+		}
+		hash := res.Hash
+		if hash == "" {
+			// the endpoint already knew the transaction, compute its hash ourselves
+			var err error
+			if hash, err = rawTxHash(rawTx); err != nil {
+				lastErr = err
+				continue
+			}
+		}
+		// forward the outcome of the remaining endpoints in the background
+		go func(remaining int) {
+			for ; remaining > 0; remaining-- {
+				results <- <-done
+			}
+			close(results)
+		}(len(handlers) - n - 1)
+		return hash, results, nil
+	}
+	close(results)
+	return "", results, lastErr
+}
+
+// isAlreadyKnown returns true if the error returned by eth_sendRawTransaction means the node
+// already has the transaction in its pool or chain
+func isAlreadyKnown(err error) bool {
+	var obj *ErrorObject
+	if !errors.As(err, &obj) {
+		return false
+	}
+	msg := strings.ToLower(obj.Message)
+	for _, s := range []string{"already known", "known transaction", "already imported", "already exists", "alreadyknown"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// rawTxHash returns the hash of a hex-encoded signed transaction
+func rawTxHash(rawTx string) (string, error) {
+	buf, err := hex.DecodeString(strings.TrimPrefix(rawTx, "0x"))
+	if err != nil {
+		return "", err
+	}
+	h := sha3.NewLegacyKeccak256()
+	h.Write(buf)
+	return "0x" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// broadcastRaw is used by RPCList.DoCtx to send eth_sendRawTransaction to all servers
+func (r RPCList) broadcastRaw(ctx context.Context, rawTx string) (json.RawMessage, error) {
+	hash, _, err := r.Broadcast(ctx, rawTx)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(hash)
+}
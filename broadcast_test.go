@@ -0,0 +1,135 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// broadcastTestHandler answers eth_sendRawTransaction with the given hash, or fails with msg
+func broadcastTestHandler(hash, msg string) RequestHandlerFunc {
+	return func(ctx context.Context, req *Request) (json.RawMessage, error) {
+		if msg != "" {
+			return nil, &ErrorObject{Code: -32000, Message: msg}
+		}
+		return json.Marshal(hash)
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	const (
+		rawTx  = "0x"
+		txHash = "0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470" // keccak256 of no data
+	)
+	tests := []struct {
+		name     string
+		handlers []Handler
+		expect   string
+		err      string
+		known    int
+	}{
+		{"accepted", []Handler{broadcastTestHandler(txHash, ""), broadcastTestHandler("", "nonce too low")}, txHash, "", 0},
+		{"already known", []Handler{broadcastTestHandler("", "already known"), broadcastTestHandler("", "nonce too low")}, txHash, "", 1},
+		{"known transaction", []Handler{broadcastTestHandler("", "Known transaction"), broadcastTestHandler("", "AlreadyKnown")}, txHash, "", 2},
+		{"all failed", []Handler{broadcastTestHandler("", "nonce too low"), broadcastTestHandler("", "nonce too low")}, "", "nonce too low", 0},
+		{"none", nil, "", ErrNoAvailableServer.Error(), 0},
+	}
+	for _, test := range tests {
+		hash, results, err := RPCList{}.Broadcast(context.Background(), rawTx, test.handlers...)
+		if hash != test.expect || (err == nil) != (test.err == "") || err != nil && !strings.HasSuffix(err.Error(), test.err) {
+			t.Errorf("%s: got %s, %v", test.name, hash, err)
+		}
+		if results == nil {
+			continue
+		}
+		count, known := 0, 0
+		for res := range results {
+			count++
+			if res.Known {
+				known++
+			}
+		}
+		if count != len(test.handlers) || known != test.known {
+			t.Errorf("%s: got %d results, %d known", test.name, count, known)
+		}
+	}
+}
+
+func TestBroadcastResults(t *testing.T) {
+	const txHash = "0x01"
+	hash, results, err := RPCList{}.Broadcast(context.Background(), "0x00",
+		broadcastTestHandler(txHash, ""),
+		broadcastTestHandler(txHash, ""),
+		broadcastTestHandler("", "insufficient funds"),
+	)
+	if err != nil || hash != txHash {
+		t.Fatalf("got %s, %v", hash, err)
+	}
+	var outcomes []string
+	for res := range results {
+		var obj *ErrorObject
+		if errors.As(res.Err, &obj) {
+			outcomes = append(outcomes, obj.Message)
+		} else {
+			outcomes = append(outcomes, res.Hash)
+		}
+	}
+	sort.Strings(outcomes)
+	if len(outcomes) != 3 || outcomes[0] != txHash || outcomes[1] != txHash || outcomes[2] != "insufficient funds" {
+		t.Errorf("results %v", outcomes)
+	}
+}
+
+func TestRPCListBroadcastRaw(t *testing.T) {
+	var hits atomic.Int32
+	var list RPCList
+	for range 3 {
+		srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			hits.Add(1)
+			var req Request
+			json.NewDecoder(r.Body).Decode(&req)
+			json.NewEncoder(rw).Encode(map[string]any{"jsonrpc": "2.0", "id": req.Id, "result": "0x02"})
+		}))
+		defer srv.Close()
+		list = append(list, New(srv.URL))
+	}
+
+	hash, err := ReadString(list.DoCtx(context.Background(), "eth_sendRawTransaction", "0x00"))
+	if err != nil || hash != "0x02" {
+		t.Fatalf("got %s, %v", hash, err)
+	}
+	// the other servers are still sent the transaction after the first answer
+	deadline := time.Now().Add(time.Second)
+	for hits.Load() != 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := hits.Load(); n != 3 {
+		t.Errorf("%d servers received the transaction", n)
+	}
+}
+
+func TestIsAlreadyKnown(t *testing.T) {
+	tests := []struct {
+		err    error
+		expect bool
+	}{
+		{&ErrorObject{Code: -32000, Message: "already known"}, true},
+		{&ErrorObject{Code: -32000, Message: "ALREADY_EXISTS: already known"}, true},
+		{&ErrorObject{Code: -32010, Message: "Transaction with the same hash was already imported."}, true},
+		{&ErrorObject{Code: -32000, Message: "nonce too low"}, false},
+		{errors.New("already known"), false},
+		{nil, false},
+	}
+	for _, test := range tests {
+		if res := isAlreadyKnown(test.err); res != test.expect {
+			t.Errorf("isAlreadyKnown(%v) = %v", test.err, res)
+		}
+	}
+}
@@ -0,0 +1,411 @@
+package ethrpc
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache is a [Handler] caching the results of another handler. Results that cannot change, such
+// as calls pinned to a block hash or to a finalized block, are kept until evicted, while results
+// depending on the latest state are kept for LatestTTL. Concurrent identical requests are
+// collapsed into a single upstream request. Methods that are not known to be read-only are
+// never cached. Callers receive their own copy of results and may modify them.
+type Cache struct {
+	Handler       Handler
+	MaxSize       int           // maximum total size of cached results in bytes, defaults to 32MB
+	LatestTTL     time.Duration // how long results depending on the latest state are kept, defaults to 2s. Negative disables.
+	FinalizedTTL  time.Duration // how often the finalized block number is refreshed, defaults to 30s
+	FinalityDepth uint64        // blocks after which a block is considered final if the node does not support the "finalized" tag, defaults to 64
+
+	lk       sync.Mutex
+	entries  map[string]*list.Element
+	lru      *list.List
+	size     int
+	inflight map[string]*cacheCall
+
+	finLk       sync.Mutex
+	finalized   uint64
+	finalizedAt time.Time
+	finCall     *finalizedCall
+}
+
+type cacheEntry struct {
+	key     string
+	value   json.RawMessage
+	expires time.Time // zero if the value never expires
+}
+
+type cacheCall struct {
+	done  chan struct{}
+	value json.RawMessage
+	err   error
+}
+
+// finalizedCall is a refresh of the finalized block number shared by concurrent callers
+type finalizedCall struct {
+	done  chan struct{}
+	block uint64
+	ok    bool
+}
+
+// cachePolicy describes how the result of a request can be cached
+type cachePolicy int
+
+const (
+	cacheNever     cachePolicy = iota
+	cacheImmutable             // result never changes
+	cacheLatest                // result depends on the latest state
+	cacheBlock                 // result is immutable if the block is finalized
+	cacheTx                    // result is immutable once the transaction is finalized
+)
+
+// methods taking a block parameter, and its position
+var cacheBlockParam = map[string]int{
+	"eth_getBalance":                          1,
+	"eth_getCode":                             1,
+	"eth_getTransactionCount":                 1,
+	"eth_getStorageAt":                        2,
+	"eth_call":                                1,
+	"eth_estimateGas":                         1,
+	"eth_feeHistory":                          1,
+	"eth_getProof":                            2,
+	"eth_getBlockByNumber":                    0,
+	"eth_getBlockReceipts":                    0,
+	"eth_getBlockTransactionCountByNumber":    0,
+	"eth_getTransactionByBlockNumberAndIndex": 0,
+	"eth_getUncleCountByBlockNumber":          0,
+}
+
+var cacheMethods = map[string]cachePolicy{
+	"eth_chainId":                           cacheImmutable,
+	"net_version":                           cacheImmutable,
+	"eth_getBlockByHash":                    cacheImmutable,
+	"eth_getBlockTransactionCountByHash":    cacheImmutable,
+	"eth_getTransactionByBlockHashAndIndex": cacheImmutable,
+	"eth_getUncleCountByBlockHash":          cacheImmutable,
+	"eth_blockNumber":                       cacheLatest,
+	"eth_gasPrice":                          cacheLatest,
+	"eth_maxPriorityFeePerGas":              cacheLatest,
+	"eth_blobBaseFee":                       cacheLatest,
+	"eth_getTransactionByHash":              cacheTx,
+	"eth_getTransactionReceipt":             cacheTx,
+}
+
+// NewCache returns a new [Cache] in front of h
+func NewCache(h Handler) *Cache {
+	return &Cache{Handler: h}
+}
+
+// DoCtx performs the request, returning a cached result if available
+func (c *Cache) DoCtx(ctx context.Context, method string, args ...any) (json.RawMessage, error) {
+	policy, block := cachePolicyFor(method, args)
+	if policy == cacheNever {
+		return c.Handler.DoCtx(ctx, method, args...)
+	}
+	params, err := json.Marshal(args)
+	if err != nil {
+		return c.Handler.DoCtx(ctx, method, args...)
+	}
+	key := method + string(params)
+
+	c.lk.Lock()
+	c.init()
+	if e, ok := c.entries[key]; ok {
+		entry := e.Value.(*cacheEntry)
+		if entry.expires.IsZero() || time.Now().Before(entry.expires) {
+			c.lru.MoveToFront(e)
+			c.lk.Unlock()
+			return slices.Clone(entry.value), nil
+		}
+		c.remove(e)
+	}
+	call, ok := c.inflight[key]
+	if !ok {
+		call = &cacheCall{done: make(chan struct{})}
+		c.inflight[key] = call
+		// the upstream request is not cancelled if the first caller goes away, as other callers
+		// may be waiting for it
+		go c.fetch(context.WithoutCancel(ctx), call, key, policy, block, method, args)
+	}
+	c.lk.Unlock()
+
+	select {
+	case <-call.done:
+		return slices.Clone(call.value), call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *Cache) fetch(ctx context.Context, call *cacheCall, key string, policy cachePolicy, block uint64, method string, args []any) {
+	call.value, call.err = c.Handler.DoCtx(ctx, method, args...)
+	if call.err == nil {
+		c.save(ctx, key, call.value, policy, block)
+	}
+
+	c.lk.Lock()
+	delete(c.inflight, key)
+	c.lk.Unlock()
+	close(call.done)
+}
+
+// save stores the result of a request according to its policy
+func (c *Cache) save(ctx context.Context, key string, value json.RawMessage, policy cachePolicy, block uint64) {
+	switch policy {
+	case cacheTx:
+		var tx struct {
+			BlockNumber string `json:"blockNumber"`
+		}
+		if json.Unmarshal(value, &tx) != nil || tx.BlockNumber == "" {
+			policy = cacheLatest
+			break
+		}
+		var err error
+		if block, err = parseQuantity(tx.BlockNumber); err != nil {
+			policy = cacheLatest
+			break
+		}
+		fallthrough
+	case cacheBlock:
+		if fin, ok := c.finalizedBlock(ctx); ok && block <= fin {
+			policy = cacheImmutable
+		} else {
+			policy = cacheLatest
+		}
+	}
+	if policy == cacheImmutable && bytes.Equal(bytes.TrimSpace(value), []byte("null")) {
+		// the block or transaction may not be known to the node yet
+		policy = cacheLatest
+	}
+
+	var expires time.Time
+	if policy == cacheLatest {
+		ttl := c.LatestTTL
+		if ttl == 0 {
+			ttl = 2 * time.Second
+		}
+		if ttl < 0 {
+			return
+		}
+		expires = time.Now().Add(ttl)
+	}
+	c.store(key, value, expires)
+}
+
+func (c *Cache) init() {
+	if c.entries == nil {
+		c.entries = make(map[string]*list.Element)
+		c.lru = list.New()
+		c.inflight = make(map[string]*cacheCall)
+	}
+}
+
+func (c *Cache) maxSize() int {
+	if c.MaxSize <= 0 {
+		return 32 << 20
+	}
+	return c.MaxSize
+}
+
+func (c *Cache) store(key string, value json.RawMessage, expires time.Time) {
+	size := cacheEntrySize(key, value)
+	max := c.maxSize()
+	if size > max/4 {
+		// too large to be worth caching
+		return
+	}
+
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	c.init()
+	if e, ok := c.entries[key]; ok {
+		c.remove(e)
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, value: value, expires: expires})
+	c.size += size
+	for c.size > max {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *Cache) remove(e *list.Element) {
+	entry := e.Value.(*cacheEntry)
+	c.lru.Remove(e)
+	delete(c.entries, entry.key)
+	c.size -= cacheEntrySize(entry.key, entry.value)
+}
+
+// cacheEntrySize returns the approximate memory used by an entry
+func cacheEntrySize(key string, value json.RawMessage) int {
+	return len(key) + len(value) + 128
+}
+
+// Size returns the number of cached results and their approximate total size in bytes
+func (c *Cache) Size() (int, int) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	return len(c.entries), c.size
+}
+
+// Purge removes all cached results
+func (c *Cache) Purge() {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	c.init()
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	c.size = 0
+}
+
+// finalizedBlock returns the number of the latest finalized block. Concurrent callers share a
+// single refresh, performed without holding finLk.
+func (c *Cache) finalizedBlock(ctx context.Context) (uint64, bool) {
+	ttl := c.FinalizedTTL
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+
+	c.finLk.Lock()
+	if !c.finalizedAt.IsZero() && time.Since(c.finalizedAt) < ttl {
+		fin := c.finalized
+		c.finLk.Unlock()
+		return fin, true
+	}
+	call := c.finCall
+	if call == nil {
+		call = &finalizedCall{done: make(chan struct{})}
+		c.finCall = call
+		go c.refreshFinalized(context.WithoutCancel(ctx), call)
+	}
+	c.finLk.Unlock()
+
+	select {
+	case <-call.done:
+		return call.block, call.ok
+	case <-ctx.Done():
+		return 0, false
+	}
+}
+
+func (c *Cache) refreshFinalized(ctx context.Context, call *finalizedCall) {
+	call.block, call.ok = c.fetchFinalized(ctx)
+
+	c.finLk.Lock()
+	if call.ok {
+		c.finalized = call.block
+		c.finalizedAt = time.Now()
+	}
+	c.finCall = nil
+	c.finLk.Unlock()
+	close(call.done)
+}
+
+// fetchFinalized queries the number of the latest finalized block
+func (c *Cache) fetchFinalized(ctx context.Context) (uint64, bool) {
+	var head struct {
+		Number string `json:"number"`
+	}
+	v, err := c.Handler.DoCtx(ctx, "eth_getBlockByNumber", "finalized", false)
+	if err == nil && json.Unmarshal(v, &head) == nil && head.Number != "" {
+		fin, err := parseQuantity(head.Number)
+		return fin, err == nil
+	}
+	// node does not support the finalized tag
+	latest, err := ReadUint64(c.Handler.DoCtx(ctx, "eth_blockNumber"))
+	if err != nil {
+		return 0, false
+	}
+	depth := c.FinalityDepth
+	if depth == 0 {
+		depth = 64
+	}
+	if latest > depth {
+		return latest - depth, true
+	}
+	return 0, true
+}
+
+// cachePolicyFor returns how the result of the given request can be cached, and the block number
+// for cacheBlock
+func cachePolicyFor(method string, args []any) (cachePolicy, uint64) {
+	if policy, ok := cacheMethods[method]; ok {
+		return policy, 0
+	}
+	if method == "eth_getLogs" {
+		if len(args) != 1 {
+			return cacheNever, 0
+		}
+		var q struct {
+			ToBlock   any `json:"toBlock"`
+			BlockHash any `json:"blockHash"`
+		}
+		if !cacheNormalize(args[0], &q) {
+			return cacheNever, 0
+		}
+		if q.BlockHash != nil {
+			return cacheImmutable, 0
+		}
+		return cacheBlockPolicy(q.ToBlock)
+	}
+	pos, ok := cacheBlockParam[method]
+	if !ok {
+		return cacheNever, 0
+	}
+	if pos >= len(args) {
+		// block defaults to latest
+		return cacheLatest, 0
+	}
+	var block any
+	if !cacheNormalize(args[pos], &block) {
+		return cacheNever, 0
+	}
+	return cacheBlockPolicy(block)
+}
+
+// cacheNormalize converts v to its json representation decoded into target
+func cacheNormalize(v, target any) bool {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(buf, target) == nil
+}
+
+// cacheBlockPolicy returns the policy for a block parameter, which can be a tag, a number, a hash
+// or an EIP-1898 object
+func cacheBlockPolicy(block any) (cachePolicy, uint64) {
+	switch b := block.(type) {
+	case nil:
+		return cacheLatest, 0
+	case map[string]any:
+		if _, ok := b["blockHash"]; ok {
+			return cacheImmutable, 0
+		}
+		return cacheBlockPolicy(b["blockNumber"])
+	case float64:
+		return cacheBlock, uint64(b)
+	case string:
+		switch {
+		case b == "earliest":
+			return cacheImmutable, 0
+		case len(b) == 66 && strings.HasPrefix(b, "0x"):
+			return cacheImmutable, 0
+		case strings.HasPrefix(b, "0x"):
+			n, err := parseQuantity(b)
+			if err != nil {
+				return cacheNever, 0
+			}
+			return cacheBlock, n
+		case b == "pending":
+			return cacheNever, 0
+		}
+		return cacheLatest, 0
+	}
+	return cacheNever, 0
+}
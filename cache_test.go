@@ -0,0 +1,209 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// cacheTestNode is a handler counting requests. Results include the request count so that
+// cached values can be told apart from fresh ones. Transactions are mined in block 0x10, except
+// 0x02 which is pending and 0x03 which is mined in block 0x1000.
+type cacheTestNode struct {
+	finalized string // number of the finalized block, unsupported tag if empty
+	head      string // returned by eth_blockNumber
+	delay     time.Duration
+
+	lk    sync.Mutex
+	calls map[string]int
+}
+
+func (n *cacheTestNode) DoCtx(ctx context.Context, method string, args ...any) (json.RawMessage, error) {
+	n.lk.Lock()
+	if n.calls == nil {
+		n.calls = make(map[string]int)
+	}
+	n.calls[method]++
+	count := n.calls[method]
+	n.lk.Unlock()
+	time.Sleep(n.delay)
+
+	switch {
+	case method == "eth_getBlockByNumber" && args[0] == "finalized":
+		if n.finalized == "" {
+			return nil, &ErrorObject{Code: errInvalidParams, Message: "invalid block tag"}
+		}
+		return json.Marshal(map[string]string{"number": n.finalized})
+	case method == "eth_blockNumber" && n.head != "":
+		return json.Marshal(n.head)
+	case method == "eth_getTransactionReceipt":
+		switch args[0] {
+		case "0x02":
+			return json.RawMessage("null"), nil
+		case "0x03":
+			return json.RawMessage(`{"blockNumber":"0x1000"}`), nil
+		}
+		return json.RawMessage(`{"blockNumber":"0x10"}`), nil
+	case method == "fail":
+		return nil, errors.New("failure")
+	}
+	return json.Marshal(method + "-" + strconv.Itoa(count))
+}
+
+func (n *cacheTestNode) count(method string) int {
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	return n.calls[method]
+}
+
+func TestCachePolicy(t *testing.T) {
+	addr := "0x0000000000000000000000000000000000000001"
+	hash := "0x88df016429689c079f3b2f6ad39fa052532c56795b733da78a91ebe6a713944b"
+	tests := []struct {
+		method string
+		args   []any
+		policy cachePolicy
+		block  uint64
+	}{
+		{"eth_chainId", nil, cacheImmutable, 0},
+		{"eth_sendRawTransaction", []any{"0x00"}, cacheNever, 0},
+		{"eth_blockNumber", nil, cacheLatest, 0},
+		{"eth_getBalance", []any{addr}, cacheLatest, 0},
+		{"eth_getBalance", []any{addr, "latest"}, cacheLatest, 0},
+		{"eth_getBalance", []any{addr, "pending"}, cacheNever, 0},
+		{"eth_getBalance", []any{addr, "earliest"}, cacheImmutable, 0},
+		{"eth_getBalance", []any{addr, "0x10"}, cacheBlock, 16},
+		{"eth_getBalance", []any{addr, hash}, cacheImmutable, 0},
+		{"eth_getBalance", []any{addr, map[string]any{"blockHash": hash}}, cacheImmutable, 0},
+		{"eth_getBalance", []any{addr, map[string]any{"blockNumber": "0x20"}}, cacheBlock, 32},
+		{"eth_getBalance", []any{addr, "0xzz"}, cacheNever, 0},
+		{"eth_getStorageAt", []any{addr, "0x0", "0x5"}, cacheBlock, 5},
+		{"eth_getBlockByNumber", []any{"finalized", false}, cacheLatest, 0},
+		{"eth_getLogs", []any{&FilterQuery{FromBlock: "0x1", ToBlock: "0x9"}}, cacheBlock, 9},
+		{"eth_getLogs", []any{map[string]any{"blockHash": hash}}, cacheImmutable, 0},
+		{"eth_getLogs", []any{map[string]any{}}, cacheLatest, 0},
+		{"eth_getTransactionReceipt", []any{hash}, cacheTx, 0},
+	}
+	for _, test := range tests {
+		policy, block := cachePolicyFor(test.method, test.args)
+		if policy != test.policy || block != test.block {
+			t.Errorf("%s %v: got policy %d block %d, expected %d %d", test.method, test.args, policy, block, test.policy, test.block)
+		}
+	}
+}
+
+func TestCache(t *testing.T) {
+	tests := []struct {
+		name   string
+		node   *cacheTestNode
+		method string
+		args   []any
+		cached bool
+	}{
+		{"immutable", &cacheTestNode{finalized: "0x64"}, "eth_chainId", nil, true},
+		{"not cacheable", &cacheTestNode{finalized: "0x64"}, "eth_sendRawTransaction", []any{"0x00"}, false},
+		{"errors", &cacheTestNode{finalized: "0x64"}, "fail", nil, false},
+		{"latest", &cacheTestNode{finalized: "0x64"}, "eth_getBalance", []any{"0x01", "latest"}, false},
+		{"finalized block", &cacheTestNode{finalized: "0x64"}, "eth_getBalance", []any{"0x01", "0x64"}, true},
+		{"recent block", &cacheTestNode{finalized: "0x64"}, "eth_getBalance", []any{"0x01", "0x65"}, false},
+		{"finalized tx", &cacheTestNode{finalized: "0x64"}, "eth_getTransactionReceipt", []any{"0x01"}, true},
+		{"pending tx", &cacheTestNode{finalized: "0x64"}, "eth_getTransactionReceipt", []any{"0x02"}, false},
+		{"recent tx", &cacheTestNode{finalized: "0x64"}, "eth_getTransactionReceipt", []any{"0x03"}, false},
+		{"finality depth", &cacheTestNode{head: "0x100"}, "eth_getBalance", []any{"0x01", "0xc0"}, true},
+		{"above finality depth", &cacheTestNode{head: "0x100"}, "eth_getBalance", []any{"0x01", "0xc1"}, false},
+	}
+	for _, test := range tests {
+		c := NewCache(test.node)
+		c.LatestTTL = -1 // only immutable results are kept
+		first, err1 := c.DoCtx(context.Background(), test.method, test.args...)
+		second, err2 := c.DoCtx(context.Background(), test.method, test.args...)
+		calls := test.node.count(test.method)
+		if test.cached {
+			if calls != 1 || err1 != nil || string(first) != string(second) {
+				t.Errorf("%s: expected a cached result, got %d calls, %s %v, %s %v", test.name, calls, first, err1, second, err2)
+			}
+		} else if calls != 2 {
+			t.Errorf("%s: expected no caching, got %d calls", test.name, calls)
+		}
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	node := &cacheTestNode{}
+	c := NewCache(node)
+	c.LatestTTL = 20 * time.Millisecond
+
+	for range 3 {
+		if res, err := c.DoCtx(context.Background(), "eth_blockNumber"); err != nil || string(res) != `"eth_blockNumber-1"` {
+			t.Fatalf("got %s, %v", res, err)
+		}
+	}
+	time.Sleep(30 * time.Millisecond)
+	if res, err := c.DoCtx(context.Background(), "eth_blockNumber"); err != nil || string(res) != `"eth_blockNumber-2"` {
+		t.Errorf("expired result was returned: %s, %v", res, err)
+	}
+}
+
+func TestCacheSingleflight(t *testing.T) {
+	node := &cacheTestNode{delay: 20 * time.Millisecond}
+	c := NewCache(node)
+
+	var wg sync.WaitGroup
+	results := make([]string, 10)
+	for n := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, _ := c.DoCtx(context.Background(), "eth_chainId")
+			results[n] = string(res)
+		}()
+	}
+	wg.Wait()
+	if calls := node.count("eth_chainId"); calls != 1 {
+		t.Errorf("concurrent requests performed %d upstream calls", calls)
+	}
+	for _, res := range results {
+		if res != `"eth_chainId-1"` {
+			t.Errorf("unexpected result %s", res)
+		}
+	}
+
+	// a caller going away does not cancel the request of the others
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if _, err := c.DoCtx(ctx, "net_version"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v, expected context.DeadlineExceeded", err)
+	}
+	if res, err := c.DoCtx(context.Background(), "net_version"); err != nil || string(res) != `"net_version-1"` {
+		t.Errorf("got %s, %v", res, err)
+	}
+}
+
+func TestCacheCopy(t *testing.T) {
+	c := NewCache(&cacheTestNode{})
+	res, _ := c.DoCtx(context.Background(), "eth_chainId")
+	res[1] = 'X'
+	if res, _ := c.DoCtx(context.Background(), "eth_chainId"); string(res) != `"eth_chainId-1"` {
+		t.Errorf("cached value was modified: %s", res)
+	}
+}
+
+func TestCacheMaxSize(t *testing.T) {
+	c := NewCache(&cacheTestNode{})
+	c.MaxSize = 1000
+	for n := range 20 {
+		c.DoCtx(context.Background(), "eth_getBlockByHash", "0x"+strconv.Itoa(n), false)
+	}
+	count, size := c.Size()
+	if size > 1000 || count == 0 || count >= 20 {
+		t.Errorf("cache holds %d entries for %d bytes", count, size)
+	}
+	c.Purge()
+	if count, size := c.Size(); count != 0 || size != 0 {
+		t.Errorf("purged cache holds %d entries for %d bytes", count, size)
+	}
+}
@@ -0,0 +1,167 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"strconv"
+
+	"github.com/ModChain/ethrpc/abi"
+)
+
+// CallMsg holds the parameters of eth_call and eth_estimateGas
+type CallMsg struct {
+	From                 *abi.Address
+	To                   *abi.Address
+	Gas                  uint64
+	GasPrice             *big.Int
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+	Value                *big.Int
+	Data                 []byte
+}
+
+type callMsgJSON struct {
+	From                 *abi.Address `json:"from,omitempty"`
+	To                   *abi.Address `json:"to,omitempty"`
+	Gas                  string       `json:"gas,omitempty"`
+	GasPrice             string       `json:"gasPrice,omitempty"`
+	MaxFeePerGas         string       `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas string       `json:"maxPriorityFeePerGas,omitempty"`
+	Value                string       `json:"value,omitempty"`
+	Data                 string       `json:"data,omitempty"`
+}
+
+// MarshalJSON encodes the message as expected by the RPC server, with quantities in hex
+func (msg *CallMsg) MarshalJSON() ([]byte, error) {
+	res := &callMsgJSON{
+		From:                 msg.From,
+		To:                   msg.To,
+		GasPrice:             hexBig(msg.GasPrice),
+		MaxFeePerGas:         hexBig(msg.MaxFeePerGas),
+		MaxPriorityFeePerGas: hexBig(msg.MaxPriorityFeePerGas),
+		Value:                hexBig(msg.Value),
+	}
+	if msg.Gas != 0 {
+		res.Gas = hexUint(msg.Gas)
+	}
+	if len(msg.Data) > 0 {
+		res.Data = "0x" + hex.EncodeToString(msg.Data)
+	}
+	return json.Marshal(res)
+}
+
+// hexUint returns v as a hex quantity
+func hexUint(v uint64) string {
+	return "0x" + strconv.FormatUint(v, 16)
+}
+
+// hexBig returns v as a hex quantity, or an empty string if v is nil
+func hexBig(v *big.Int) string {
+	if v == nil {
+		return ""
+	}
+	return "0x" + v.Text(16)
+}
+
+// Call performs eth_call with the given message at the given block ("latest" if empty) and
+// returns the raw result
+func (a *Api) Call(ctx context.Context, msg *CallMsg, block string) ([]byte, error) {
+	if block == "" {
+		block = "latest"
+	}
+	return ReadBytes(a.Handler.DoCtx(ctx, "eth_call", msg, block))
+}
+
+// GetCode returns the code deployed at the given address at the given block ("latest" if empty)
+func (a *Api) GetCode(ctx context.Context, addr abi.Address, block string) ([]byte, error) {
+	if block == "" {
+		block = "latest"
+	}
+	return ReadBytes(a.Handler.DoCtx(ctx, "eth_getCode", addr, block))
+}
+
+// EstimateGas returns the amount of gas needed to run the given message
+func (a *Api) EstimateGas(ctx context.Context, msg *CallMsg) (uint64, error) {
+	return ReadUint64(a.Handler.DoCtx(ctx, "eth_estimateGas", msg))
+}
+
+// GasPrice returns the current gas price suggested by the node
+func (a *Api) GasPrice(ctx context.Context) (*big.Int, error) {
+	return ReadBigInt(a.Handler.DoCtx(ctx, "eth_gasPrice"))
+}
+
+// MaxPriorityFeePerGas returns the priority fee suggested by the node for EIP-1559 transactions
+func (a *Api) MaxPriorityFeePerGas(ctx context.Context) (*big.Int, error) {
+	return ReadBigInt(a.Handler.DoCtx(ctx, "eth_maxPriorityFeePerGas"))
+}
+
+// GetTransactionCount returns the nonce of the given address at the given block ("pending" if empty)
+func (a *Api) GetTransactionCount(ctx context.Context, addr abi.Address, block string) (uint64, error) {
+	if block == "" {
+		block = "pending"
+	}
+	return ReadUint64(a.Handler.DoCtx(ctx, "eth_getTransactionCount", addr, block))
+}
+
+// SendRawTransaction sends a signed transaction and returns its hash
+func (a *Api) SendRawTransaction(ctx context.Context, raw []byte) (string, error) {
+	return ReadString(a.Handler.DoCtx(ctx, "eth_sendRawTransaction", "0x"+hex.EncodeToString(raw)))
+}
+
+// PrepareTransaction returns a transaction ready to be signed, with nonce, fees and gas filled from
+// the node. EIP-1559 transactions are used if the latest block has a base fee.
+func (a *Api) PrepareTransaction(ctx context.Context, from abi.Address, to *abi.Address, value *big.Int, data []byte) (*Transaction, error) {
+	chainId, err := a.ChainId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := a.GetTransactionCount(ctx, from, "pending")
+	if err != nil {
+		return nil, err
+	}
+	tx := &Transaction{ChainId: chainId, Nonce: nonce, To: to, Value: value, Data: data}
+
+	head, err := a.HeaderByNumber(ctx, "latest")
+	if err != nil {
+		return nil, err
+	}
+	if head == nil {
+		return nil, ErrInvalidResponse
+	}
+	if head.BaseFeePerGas != nil {
+		tip, err := a.MaxPriorityFeePerGas(ctx)
+		if err != nil {
+			// not all nodes support this method
+			tip = big.NewInt(1_000_000_000)
+		}
+		tx.Type = DynamicFeeTxType
+		tx.MaxPriorityFeePerGas = tip
+		tx.MaxFeePerGas = new(big.Int).Add(new(big.Int).Mul(head.BaseFeePerGas, big.NewInt(2)), tip)
+	} else {
+		tx.Type = LegacyTxType
+		tx.GasPrice, err = a.GasPrice(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tx.Gas, err = a.EstimateGas(ctx, &CallMsg{From: &from, To: to, Value: value, Data: data})
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// SendTransaction signs the transaction with the given signer and sends it
+func (a *Api) SendTransaction(ctx context.Context, s Signer, tx *Transaction) (string, error) {
+	if err := tx.Sign(s); err != nil {
+		return "", err
+	}
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	return a.SendRawTransaction(ctx, raw)
+}
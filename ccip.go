@@ -0,0 +1,196 @@
+package ethrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/ModChain/ethrpc/abi"
+)
+
+var (
+	offchainLookupABI = abi.MustParseHuman(
+		"error OffchainLookup(address sender, string[] urls, bytes callData, bytes4 callbackFunction, bytes extraData)",
+	)
+	offchainLookupError = offchainLookupABI.Error("OffchainLookup")
+	ccipCallbackArgs    = abi.Arguments{{Type: abi.MustParseType("bytes")}, {Type: abi.MustParseType("bytes")}}
+)
+
+// CCIPRead configures EIP-3668 offchain lookups performed by [Api.CallCCIP]
+type CCIPRead struct {
+	HTTPClient  *http.Client // defaults to the HTTPClient of the [RPC] handler, or http.DefaultClient
+	MaxLookups  int          // maximum number of chained lookups for one call, defaults to 4
+	AllowedURLs []string     // gateway URL prefixes that can be queried, all URLs are allowed if empty. Scheme and host must match exactly, and the path must be within the prefix's path.
+}
+
+// offchainLookup holds the decoded OffchainLookup revert
+type offchainLookup struct {
+	Sender           abi.Address
+	Urls             []string
+	CallData         []byte
+	CallbackFunction [4]byte
+	ExtraData        []byte
+}
+
+// CallCCIP performs eth_call like [Api.Call], but if the contract reverts with OffchainLookup
+// the data is fetched from the gateway and the callback function is called with the result. A
+// nil cfg uses default settings.
+func (a *Api) CallCCIP(ctx context.Context, msg *CallMsg, block string, cfg *CCIPRead) ([]byte, error) {
+	if cfg == nil {
+		cfg = &CCIPRead{}
+	}
+	maxLookups := cfg.MaxLookups
+	if maxLookups <= 0 {
+		maxLookups = 4
+	}
+	call := *msg
+
+	for n := 0; ; n++ {
+		res, err := a.Call(ctx, &call, block)
+		if err == nil {
+			return res, nil
+		}
+		data, ok := revertData(err)
+		if !ok || call.To == nil || !bytes.HasPrefix(data, offchainLookupError.Selector()) {
+			return nil, err
+		}
+		if n >= maxLookups {
+			return nil, fmt.Errorf("%w: more than %d lookups", ErrOffchainLookup, maxLookups)
+		}
+		var lookup offchainLookup
+		if err := offchainLookupError.Inputs.UnpackInto(&lookup, data[4:]); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrOffchainLookup, err)
+		}
+		if lookup.Sender != *call.To {
+			return nil, fmt.Errorf("%w: sender %s does not match %s", ErrOffchainLookup, lookup.Sender, call.To)
+		}
+		resp, err := a.ccipFetch(ctx, cfg, &lookup)
+		if err != nil {
+			return nil, err
+		}
+		args, err := ccipCallbackArgs.Pack(resp, lookup.ExtraData)
+		if err != nil {
+			return nil, err
+		}
+		call.Data = append(lookup.CallbackFunction[:], args...)
+	}
+}
+
+// ccipFetch queries the gateway urls in order until one of them returns a response
+func (a *Api) ccipFetch(ctx context.Context, cfg *CCIPRead, lookup *offchainLookup) ([]byte, error) {
+	client := cfg.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+		if r, ok := a.Handler.(*RPC); ok && r.HTTPClient != nil {
+			client = r.HTTPClient
+		}
+	}
+	if len(cfg.AllowedURLs) > 0 {
+		// redirects must not lead outside of the allowed urls
+		c := *client
+		next := client.CheckRedirect
+		c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if !cfg.allowed(req.URL.String()) {
+				return fmt.Errorf("%w: redirect to a gateway url that is not allowed", ErrOffchainLookup)
+			}
+			if next != nil {
+				return next(req, via)
+			}
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return nil
+		}
+		client = &c
+	}
+	sender := "0x" + hex.EncodeToString(lookup.Sender[:])
+	data := "0x" + hex.EncodeToString(lookup.CallData)
+
+	var lastErr error = fmt.Errorf("%w: no allowed gateway url", ErrOffchainLookup)
+	for _, u := range lookup.Urls {
+		get := strings.Contains(u, "{data}")
+		u = strings.NewReplacer("{sender}", sender, "{data}", data).Replace(u)
+		if !cfg.allowed(u) {
+			continue
+		}
+		var req *http.Request
+		var err error
+		if get {
+			req, err = http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		} else {
+			body, _ := json.Marshal(map[string]string{"data": data, "sender": sender})
+			req, err = http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+			if req != nil {
+				req.Header.Set("Content-Type", "application/json")
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrOffchainLookup, err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("%w: %w", ErrOffchainLookup, err)
+			continue
+		}
+		buf, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+		resp.Body.Close()
+		if err != nil {
+			lastErr = fmt.Errorf("%w: %w", ErrOffchainLookup, err)
+			continue
+		}
+		if resp.StatusCode >= 400 {
+			lastErr = fmt.Errorf("%w: gateway returned %s", ErrOffchainLookup, resp.Status)
+			if resp.StatusCode < 500 {
+				// client errors are final as per EIP-3668
+				return nil, lastErr
+			}
+			continue
+		}
+		var res struct {
+			Data string `json:"data"`
+		}
+		if err := json.Unmarshal(buf, &res); err != nil {
+			lastErr = fmt.Errorf("%w: %w", ErrOffchainLookup, err)
+			continue
+		}
+		out, err := hex.DecodeString(strings.TrimPrefix(res.Data, "0x"))
+		if err != nil {
+			lastErr = fmt.Errorf("%w: %w", ErrOffchainLookup, err)
+			continue
+		}
+		return out, nil
+	}
+	return nil, lastErr
+}
+
+// allowed returns true if the gateway url u matches one of AllowedURLs
+func (cfg *CCIPRead) allowed(u string) bool {
+	if len(cfg.AllowedURLs) == 0 {
+		return true
+	}
+	target, err := url.Parse(u)
+	if err != nil || target.User != nil {
+		return false
+	}
+	// dot segments are resolved so they cannot escape the allowed path
+	cleaned := path.Clean("/" + target.Path)
+	for _, p := range cfg.AllowedURLs {
+		allow, err := url.Parse(p)
+		if err != nil || !strings.EqualFold(allow.Scheme, target.Scheme) || !strings.EqualFold(allow.Host, target.Host) {
+			continue
+		}
+		prefix := strings.TrimSuffix(allow.Path, "/")
+		if prefix == "" || cleaned == prefix || strings.HasPrefix(cleaned, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,217 @@
+package ethrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ModChain/ethrpc/abi"
+)
+
+var (
+	ccipTestContract = abi.MustParseAddress("0x00000000000000000000000000000000000cc1b0")
+	ccipTestCall     = []byte{0x11, 0x11, 0x11, 0x11}
+	ccipTestCallback = [4]byte{0x22, 0x22, 0x22, 0x22}
+	ccipTestExtra    = []byte{0xbe, 0xef}
+)
+
+// ccipTestNode returns a json-rpc server whose eth_call reverts with OffchainLookup for the given
+// urls. The callback returns the gateway response, unless loop is set in which case it reverts
+// again.
+func ccipTestNode(t *testing.T, urls []string, loop bool) *httptest.Server {
+	revert, err := offchainLookupError.Inputs.Pack(ccipTestContract, urls, []byte{0xde, 0xad}, ccipTestCallback, ccipTestExtra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	revert = append(offchainLookupError.Selector(), revert...)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Id     any               `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Method != "eth_call" {
+			t.Errorf("unexpected request %s: %v", req.Method, err)
+			return
+		}
+		var msg struct {
+			Data string `json:"data"`
+		}
+		json.Unmarshal(req.Params[0], &msg)
+		data, _ := hex.DecodeString(strings.TrimPrefix(msg.Data, "0x"))
+		res := map[string]any{"jsonrpc": "2.0", "id": req.Id}
+		switch {
+		case bytes.Equal(data, ccipTestCall) || (loop && bytes.HasPrefix(data, ccipTestCallback[:])):
+			res["error"] = map[string]any{"code": 3, "message": "execution reverted", "data": "0x" + hex.EncodeToString(revert)}
+		case bytes.HasPrefix(data, ccipTestCallback[:]):
+			args, err := ccipCallbackArgs.Unpack(data[4:])
+			if err != nil || !bytes.Equal(args[1].([]byte), ccipTestExtra) {
+				t.Errorf("invalid callback data %x: %v", data, err)
+			}
+			res["result"] = "0x" + hex.EncodeToString(args[0].([]byte))
+		default:
+			t.Errorf("unexpected call data %x", data)
+		}
+		json.NewEncoder(rw).Encode(res)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// ccipTestGateway returns a gateway answering GET requests under /get/, POST requests on /post,
+// and failing with the given status under /status/
+func ccipTestGateway(t *testing.T, hits *atomic.Int64) *httptest.Server {
+	sender := strings.ToLower(ccipTestContract.Hex())
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/get/"+sender+"/0xdead.json":
+			rw.Write([]byte(`{"data":"0xcafe01"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/post/"+sender:
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			if body["sender"] != sender || body["data"] != "0xdead" {
+				t.Errorf("invalid POST body %v", body)
+			}
+			rw.Write([]byte(`{"data":"0xcafe02"}`))
+		case r.URL.Path == "/status/404":
+			http.Error(rw, "not found", http.StatusNotFound)
+		case r.URL.Path == "/status/500":
+			http.Error(rw, "failure", http.StatusInternalServerError)
+		default:
+			t.Errorf("unexpected gateway request %s %s", r.Method, r.URL)
+			http.Error(rw, "unexpected", http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCallCCIP(t *testing.T) {
+	var hits atomic.Int64
+	gw := ccipTestGateway(t, &hits)
+	get := gw.URL + "/get/{sender}/{data}.json"
+
+	tests := []struct {
+		name string
+		urls []string
+		want []byte
+		err  bool
+	}{
+		{"get", []string{get}, []byte{0xca, 0xfe, 0x01}, false},
+		{"post", []string{gw.URL + "/post/{sender}"}, []byte{0xca, 0xfe, 0x02}, false},
+		{"server error falls through", []string{gw.URL + "/status/500", get}, []byte{0xca, 0xfe, 0x01}, false},
+		{"client error is final", []string{gw.URL + "/status/404", get}, nil, true},
+	}
+	for _, test := range tests {
+		node := ccipTestNode(t, test.urls, false)
+		api := &Api{New(node.URL)}
+		res, err := api.CallCCIP(context.Background(), &CallMsg{To: &ccipTestContract, Data: ccipTestCall}, "", nil)
+		switch {
+		case test.err && !errors.Is(err, ErrOffchainLookup):
+			t.Errorf("%s: got error %v, want ErrOffchainLookup", test.name, err)
+		case !test.err && err != nil:
+			t.Errorf("%s: %s", test.name, err)
+		case !test.err && !bytes.Equal(res, test.want):
+			t.Errorf("%s: got %x, want %x", test.name, res, test.want)
+		}
+	}
+}
+
+func TestCallCCIPMaxLookups(t *testing.T) {
+	var hits atomic.Int64
+	gw := ccipTestGateway(t, &hits)
+	node := ccipTestNode(t, []string{gw.URL + "/get/{sender}/{data}.json"}, true)
+	api := &Api{New(node.URL)}
+
+	_, err := api.CallCCIP(context.Background(), &CallMsg{To: &ccipTestContract, Data: ccipTestCall}, "", &CCIPRead{MaxLookups: 2})
+	if !errors.Is(err, ErrOffchainLookup) {
+		t.Fatalf("got error %v, want ErrOffchainLookup", err)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("gateway was queried %d times, want 2", n)
+	}
+}
+
+func TestCallCCIPAllowedURLs(t *testing.T) {
+	var hits atomic.Int64
+	gw := ccipTestGateway(t, &hits)
+	node := ccipTestNode(t, []string{gw.URL + "/status/500"}, false)
+	api := &Api{New(node.URL)}
+
+	_, err := api.CallCCIP(context.Background(), &CallMsg{To: &ccipTestContract, Data: ccipTestCall}, "", &CCIPRead{AllowedURLs: []string{gw.URL + "/get"}})
+	if !errors.Is(err, ErrOffchainLookup) {
+		t.Fatalf("got error %v, want ErrOffchainLookup", err)
+	}
+	if n := hits.Load(); n != 0 {
+		t.Errorf("gateway was queried %d times, want 0", n)
+	}
+}
+
+func TestCCIPReadAllowed(t *testing.T) {
+	cfg := &CCIPRead{AllowedURLs: []string{"https://gw.example.com", "https://api.example.com/ccip/"}}
+	tests := map[string]bool{
+		"https://gw.example.com/0x1234/0xdead.json":        true,
+		"https://GW.example.com/lookup":                    true,
+		"https://api.example.com/ccip/0x1234":              true,
+		"https://api.example.com/ccip":                     true,
+		"https://gw.example.com.evil.io/0x1234":            false,
+		"https://gw.example.com@evil.io/":                  false,
+		"https://user@gw.example.com/":                     false,
+		"http://gw.example.com/0x1234":                     false,
+		"https://gw.example.com:8443/0x1234":               false,
+		"https://api.example.com/ccip-evil/0x1234":         false,
+		"https://api.example.com/ccip/../admin":            false,
+		"https://api.example.com/ccip/%2e%2e/admin/0x1234": false,
+	}
+	for u, want := range tests {
+		if got := cfg.allowed(u); got != want {
+			t.Errorf("allowed(%q) = %v, want %v", u, got, want)
+		}
+	}
+}
+
+func TestCallCCIPRedirect(t *testing.T) {
+	var hits atomic.Int64
+	gw := ccipTestGateway(t, &hits)
+	redirect := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		http.Redirect(rw, r, gw.URL+"/get"+strings.TrimPrefix(r.URL.Path, "/redirect"), http.StatusFound)
+	}))
+	t.Cleanup(redirect.Close)
+	node := ccipTestNode(t, []string{redirect.URL + "/redirect/{sender}/{data}.json"}, false)
+	api := &Api{New(node.URL)}
+
+	tests := []struct {
+		name    string
+		allowed []string
+		hits    int64
+		err     bool
+	}{
+		{"all allowed", nil, 1, false},
+		{"target allowed", []string{redirect.URL + "/redirect", gw.URL + "/get"}, 1, false},
+		{"target not allowed", []string{redirect.URL + "/redirect"}, 0, true},
+	}
+	for _, test := range tests {
+		hits.Store(0)
+		res, err := api.CallCCIP(context.Background(), &CallMsg{To: &ccipTestContract, Data: ccipTestCall}, "", &CCIPRead{AllowedURLs: test.allowed})
+		switch {
+		case test.err && !errors.Is(err, ErrOffchainLookup):
+			t.Errorf("%s: got error %v, want ErrOffchainLookup", test.name, err)
+		case !test.err && err != nil:
+			t.Errorf("%s: %s", test.name, err)
+		case !test.err && !bytes.Equal(res, []byte{0xca, 0xfe, 0x01}):
+			t.Errorf("%s: got %x, want cafe01", test.name, res)
+		}
+		if n := hits.Load(); n != test.hits {
+			t.Errorf("%s: gateway was queried %d times, want %d", test.name, n, test.hits)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"go/token"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/ModChain/ethrpc/abi"
+)
+
+type bindField struct {
+	Name   string // Go name
+	GoType string
+	Tag    string // abi name, if any
+}
+
+type bindMethod struct {
+	GoName       string
+	Sig          string
+	Doc          string
+	Inputs       []*bindField
+	Outputs      []*bindField
+	OutputStruct string // set if there is more than one output
+	Constant     bool
+	Payable      bool
+}
+
+type bindEvent struct {
+	GoName string
+	Parse  string // name of the log parsing method, for events
+	Sig    string
+	Doc    string
+	Fields []*bindField
+}
+
+type bindStruct struct {
+	Name   string
+	Fields []*bindField
+}
+
+type bindContract struct {
+	Package     string
+	Type        string
+	ABI         string
+	Bin         string
+	Constructor *bindMethod
+	Methods     []*bindMethod
+	Events      []*bindEvent
+	Errors      []*bindEvent
+	Structs     []*bindStruct
+}
+
+// binder holds the state needed while generating a binding
+type binder struct {
+	typ     string
+	structs map[string]*bindStruct // by canonical type & name
+	list    []*bindStruct
+	names   map[string]bool // used Go type names
+}
+
+// Generate returns the Go source of a binding for the given ABI. bin is the hex-encoded
+// contract bytecode and can be empty.
+func Generate(abiJSON []byte, bin, pkg, typ string) ([]byte, error) {
+	parsed, err := abi.ParseJSON(abiJSON)
+	if err != nil {
+		return nil, err
+	}
+	if bin != "" {
+		if _, err := hex.DecodeString(bin); err != nil {
+			return nil, fmt.Errorf("invalid bytecode: %w", err)
+		}
+	}
+
+	// store a compact version of the abi itself in the generated code
+	var raw json.RawMessage
+	if err := json.Unmarshal(abiJSON, &struct {
+		ABI *json.RawMessage `json:"abi"`
+	}{&raw}); err != nil || raw == nil {
+		raw = abiJSON
+	}
+	compact := &bytes.Buffer{}
+	if err := json.Compact(compact, raw); err != nil {
+		return nil, err
+	}
+
+	b := &binder{typ: typ, structs: make(map[string]*bindStruct), names: map[string]bool{typ: true}}
+	c := &bindContract{Package: pkg, Type: typ, ABI: compact.String(), Bin: bin}
+
+	methodNames := map[string]bool{}
+	uniqueMethod := func(name string) string {
+		res := name
+		for n := 0; methodNames[res]; n++ {
+			res = name + strconv.Itoa(n)
+		}
+		methodNames[res] = true
+		return res
+	}
+
+	if parsed.Constructor != nil && bin != "" {
+		c.Constructor = b.method(parsed.Constructor, "Deploy"+typ)
+	}
+	for _, m := range parsed.Methods {
+		c.Methods = append(c.Methods, b.method(m, uniqueMethod(capitalise(m.Name))))
+	}
+	for _, m := range c.Methods {
+		if len(m.Outputs) > 1 {
+			m.OutputStruct = b.uniqueType(typ + m.GoName + "Output")
+		}
+	}
+	for _, e := range parsed.Events {
+		ev := &bindEvent{
+			GoName: b.uniqueType(typ + capitalise(e.Name)),
+			Sig:    e.Sig(),
+			Doc:    e.String(),
+			Fields: b.fields(e.Inputs, true),
+		}
+		ev.Parse = uniqueMethod("Parse" + capitalise(e.Name))
+		c.Events = append(c.Events, ev)
+	}
+	for _, e := range parsed.Errors {
+		c.Errors = append(c.Errors, &bindEvent{
+			GoName: b.uniqueType(typ + capitalise(e.Name) + "Error"),
+			Sig:    e.Sig(),
+			Doc:    strings.TrimPrefix(e.String(), "error "),
+			Fields: b.fields(e.Inputs, false),
+		})
+	}
+	c.Structs = b.list
+
+	buf := &bytes.Buffer{}
+	if err := bindTemplate.Execute(buf, c); err != nil {
+		return nil, err
+	}
+	res, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %w", err)
+	}
+	return res, nil
+}
+
+func (b *binder) method(m *abi.Method, goName string) *bindMethod {
+	res := &bindMethod{
+		GoName:   goName,
+		Sig:      m.Sig(),
+		Doc:      m.String(),
+		Outputs:  b.fields(m.Outputs, false),
+		Constant: m.IsConstant(),
+		Payable:  m.IsPayable(),
+	}
+	// parameter names must not conflict with the ones used by the generated code
+	used := map[string]bool{"c": true, "ctx": true, "api": true, "signer": true, "value": true, "res": true, "err": true, "values": true}
+	for n, arg := range m.Inputs {
+		name := paramName(arg.Name, n)
+		for used[name] {
+			name += "_"
+		}
+		used[name] = true
+		res.Inputs = append(res.Inputs, &bindField{Name: name, GoType: b.goType(arg.Type, arg.InternalType)})
+	}
+	return res
+}
+
+// fields returns struct fields for a list of arguments
+func (b *binder) fields(args abi.Arguments, event bool) []*bindField {
+	used := map[string]bool{}
+	if event {
+		used["Raw"] = true
+	}
+	res := make([]*bindField, 0, len(args))
+	for n, arg := range args {
+		name := capitalise(arg.Name)
+		if name == "" {
+			name = "Field" + strconv.Itoa(n)
+		}
+		for used[name] {
+			name += "_"
+		}
+		used[name] = true
+
+		f := &bindField{Name: name, Tag: arg.Name}
+		if event && arg.Indexed && arg.Type.IsHashedTopic() {
+			// only the hash of the value is available
+			f.GoType = "abi.Hash"
+		} else {
+			f.GoType = b.goType(arg.Type, arg.InternalType)
+		}
+		res = append(res, f)
+	}
+	return res
+}
+
+// goType returns the Go type used for values of the given abi type
+func (b *binder) goType(t *abi.Type, internal string) string {
+	switch t.Kind {
+	case abi.UintKind, abi.IntKind:
+		switch t.Size {
+		case 8, 16, 32, 64:
+			if t.Kind == abi.UintKind {
+				return "uint" + strconv.Itoa(t.Size)
+			}
+			return "int" + strconv.Itoa(t.Size)
+		}
+		return "*big.Int"
+	case abi.AddressKind:
+		return "abi.Address"
+	case abi.BoolKind:
+		return "bool"
+	case abi.StringKind:
+		return "string"
+	case abi.BytesKind:
+		return "[]byte"
+	case abi.FixedBytesKind, abi.FunctionKind:
+		return "[" + strconv.Itoa(t.Size) + "]byte"
+	case abi.SliceKind:
+		return "[]" + b.goType(t.Elem, trimArray(internal))
+	case abi.ArrayKind:
+		return "[" + strconv.Itoa(t.Size) + "]" + b.goType(t.Elem, trimArray(internal))
+	case abi.TupleKind:
+		return b.tupleStruct(t, internal)
+	}
+	return "any"
+}
+
+// tupleStruct returns the name of the struct generated for a tuple, creating it if needed
+func (b *binder) tupleStruct(t *abi.Type, internal string) string {
+	// "struct Pool.Key" → "Key"
+	name := strings.TrimPrefix(internal, "struct ")
+	if pos := strings.LastIndexByte(name, '.'); pos != -1 {
+		name = name[pos+1:]
+	}
+	name = capitalise(name)
+	if name == "" {
+		name = "Tuple"
+	}
+
+	key := t.String() + " " + name
+	if s, ok := b.structs[key]; ok {
+		return s.Name
+	}
+	s := &bindStruct{Name: b.uniqueType(b.typ + name)}
+	b.structs[key] = s
+	b.list = append(b.list, s)
+	s.Fields = b.fields(t.Components, false)
+	return s.Name
+}
+
+func (b *binder) uniqueType(name string) string {
+	res := name
+	for n := 0; b.names[res]; n++ {
+		res = name + strconv.Itoa(n)
+	}
+	b.names[res] = true
+	return res
+}
+
+// trimArray removes the last array suffix of an internal type name
+func trimArray(s string) string {
+	if pos := strings.LastIndexByte(s, '['); pos != -1 && strings.HasSuffix(s, "]") {
+		return s[:pos]
+	}
+	return s
+}
+
+// capitalise converts a solidity identifier to an exported Go identifier
+func capitalise(s string) string {
+	var res []rune
+	upper := true
+	for _, r := range s {
+		if r == '_' || r == '$' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		res = append(res, r)
+	}
+	return string(res)
+}
+
+// paramName converts a solidity parameter name to an unexported Go identifier
+func paramName(s string, n int) string {
+	res := capitalise(s)
+	if res == "" {
+		return "arg" + strconv.Itoa(n)
+	}
+	res = string(unicode.ToLower(rune(res[0]))) + res[1:]
+	if token.IsKeyword(res) {
+		res += "_"
+	}
+	return res
+}
+
+var bindTemplate = template.Must(template.New("bind").Parse(bindSource))
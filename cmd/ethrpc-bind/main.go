@@ -0,0 +1,75 @@
+// Command ethrpc-bind generates typed Go bindings for a contract from its ABI, using
+// ethrpc.Contract to perform calls.
+//
+// Usage:
+//
+//	ethrpc-bind -abi Token.abi.json [-bin Token.bin] -pkg token -type Token [-out token.go]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+func main() {
+	var (
+		abiFile = flag.String("abi", "", "path to the contract ABI json, or - for stdin (required)")
+		binFile = flag.String("bin", "", "path to the contract bytecode, to generate a deploy function")
+		pkg     = flag.String("pkg", "", "package name of the generated file (required)")
+		typ     = flag.String("type", "", "name of the generated Go type (defaults to the package name)")
+		out     = flag.String("out", "", "output file (defaults to stdout)")
+	)
+	flag.Parse()
+
+	if *abiFile == "" || *pkg == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *typ == "" {
+		*typ = capitalise(*pkg)
+	}
+
+	err := run(*abiFile, *binFile, *pkg, *typ, *out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ethrpc-bind: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(abiFile, binFile, pkg, typ, out string) error {
+	var (
+		abiJSON []byte
+		err     error
+	)
+	if abiFile == "-" {
+		abiJSON, err = io.ReadAll(os.Stdin)
+	} else {
+		abiJSON, err = os.ReadFile(abiFile)
+	}
+	if err != nil {
+		return err
+	}
+
+	var bin string
+	if binFile != "" {
+		buf, err := os.ReadFile(binFile)
+		if err != nil {
+			return err
+		}
+		bin = strings.TrimPrefix(strings.TrimSpace(string(buf)), "0x")
+	}
+
+	code, err := Generate(abiJSON, bin, pkg, typ)
+	if err != nil {
+		return err
+	}
+
+	if out == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return os.WriteFile(out, code, 0644)
+}
@@ -0,0 +1,190 @@
+package main
+
+const bindSource = `// Code generated by ethrpc-bind. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ModChain/ethrpc"
+	"github.com/ModChain/ethrpc/abi"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = context.Background
+	_ = hex.DecodeString
+	_ = errors.As
+	_ = fmt.Sprintf
+	_ = big.NewInt
+)
+
+{{- $T := .Type}}
+
+// {{$T}}ABI is the ABI used to generate this binding.
+const {{$T}}ABI = ` + "`{{.ABI}}`" + `
+
+{{- if .Bin}}
+
+// {{$T}}Bin is the bytecode used to deploy new {{$T}} contracts.
+const {{$T}}Bin = "{{.Bin}}"
+{{- end}}
+
+// {{$T}}Parsed is the parsed version of {{$T}}ABI.
+var {{$T}}Parsed = abi.MustParseJSON({{$T}}ABI)
+
+{{- range .Structs}}
+
+// {{.Name}} is an auto generated type for a solidity struct.
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.GoType}}{{if .Tag}} ` + "`abi:\"{{.Tag}}\"`" + `{{end}}
+{{- end}}
+}
+{{- end}}
+
+// {{$T}} is a binding to a deployed {{$T}} contract.
+type {{$T}} struct {
+	*ethrpc.Contract
+}
+
+// New{{$T}} returns a binding to the {{$T}} contract deployed at the given address.
+func New{{$T}}(addr abi.Address, api *ethrpc.Api) *{{$T}} {
+	return &{{$T}}{ethrpc.NewContract(addr, {{$T}}Parsed, api)}
+}
+
+{{- with .Constructor}}
+
+// {{.GoName}} deploys a new {{$T}} contract, returning its address and the deployment transaction hash.
+func {{.GoName}}(ctx context.Context, api *ethrpc.Api, signer ethrpc.Signer{{if .Payable}}, value *big.Int{{end}}{{range .Inputs}}, {{.Name}} {{.GoType}}{{end}}) (abi.Address, string, error) {
+	code, err := hex.DecodeString({{$T}}Bin)
+	if err != nil {
+		return abi.Address{}, "", err
+	}
+	args, err := {{$T}}Parsed.Constructor.Pack({{range $i, $f := .Inputs}}{{if $i}}, {{end}}{{.Name}}{{end}})
+	if err != nil {
+		return abi.Address{}, "", err
+	}
+	tx, err := api.PrepareTransaction(ctx, signer.Address(), nil, {{if .Payable}}value{{else}}nil{{end}}, append(code, args...))
+	if err != nil {
+		return abi.Address{}, "", err
+	}
+	hash, err := api.SendTransaction(ctx, signer, tx)
+	if err != nil {
+		return abi.Address{}, "", err
+	}
+	return ethrpc.CreateAddress(signer.Address(), tx.Nonce), hash, nil
+}
+{{- end}}
+
+{{- range .Methods}}
+{{- if .OutputStruct}}
+
+// {{.OutputStruct}} holds the values returned by {{.GoName}}.
+type {{.OutputStruct}} struct {
+{{- range .Outputs}}
+	{{.Name}} {{.GoType}}
+{{- end}}
+}
+{{- end}}
+
+{{- if .Constant}}
+
+// {{.GoName}} calls {{.Doc}}
+func (c *{{$T}}) {{.GoName}}(ctx context.Context{{range .Inputs}}, {{.Name}} {{.GoType}}{{end}}) {{if .OutputStruct}}(*{{.OutputStruct}}, error){{else if .Outputs}}({{(index .Outputs 0).GoType}}, error){{else}}error{{end}} {
+{{- if .OutputStruct}}
+	values, err := c.Contract.Call(ctx, "{{.Sig}}"{{range .Inputs}}, {{.Name}}{{end}})
+	if err != nil {
+		return nil, err
+	}
+	res := &{{.OutputStruct}}{}
+	err = {{$T}}Parsed.Method("{{.Sig}}").Outputs.CopyEach(values{{range .Outputs}}, &res.{{.Name}}{{end}})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+{{- else if .Outputs}}
+	var res {{(index .Outputs 0).GoType}}
+	err := c.Contract.CallTo(ctx, &res, "{{.Sig}}"{{range .Inputs}}, {{.Name}}{{end}})
+	return res, err
+{{- else}}
+	_, err := c.Contract.CallRaw(ctx, "{{.Sig}}"{{range .Inputs}}, {{.Name}}{{end}})
+	return err
+{{- end}}
+}
+{{- else}}
+
+// {{.GoName}} sends a transaction calling {{.Doc}}
+func (c *{{$T}}) {{.GoName}}(ctx context.Context, signer ethrpc.Signer{{if .Payable}}, value *big.Int{{end}}{{range .Inputs}}, {{.Name}} {{.GoType}}{{end}}) (string, error) {
+	return c.Contract.TransactValue(ctx, signer, {{if .Payable}}value{{else}}nil{{end}}, "{{.Sig}}"{{range .Inputs}}, {{.Name}}{{end}})
+}
+{{- end}}
+{{- end}}
+
+{{- range .Events}}
+
+// {{.GoName}} is the log emitted by {{.Doc}}
+type {{.GoName}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.GoType}}
+{{- end}}
+	Raw *ethrpc.Log
+}
+
+// {{.Parse}} decodes a {{.Sig}} log.
+func (c *{{$T}}) {{.Parse}}(log *ethrpc.Log) (*{{.GoName}}, error) {
+	ev := {{$T}}Parsed.Event("{{.Sig}}")
+	values, err := ev.DecodeLog(log.Topics, log.Data)
+	if err != nil {
+		return nil, err
+	}
+	res := &{{.GoName}}{Raw: log}
+	err = ev.Inputs.CopyEach(values{{range .Fields}}, &res.{{.Name}}{{end}})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+{{- end}}
+
+{{- if .Errors}}
+{{- range .Errors}}
+
+// {{.GoName}} is the custom error {{.Doc}}
+type {{.GoName}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.GoType}}
+{{- end}}
+}
+
+func (e *{{.GoName}}) Error() string {
+	return fmt.Sprintf("{{.Sig}}: %+v", *e)
+}
+{{- end}}
+
+// Decode{{$T}}Error returns one of the typed errors of {{$T}} if err is a revert with a known custom
+// error, or err otherwise.
+func Decode{{$T}}Error(err error) error {
+	var rev *ethrpc.RevertError
+	if !errors.As(err, &rev) || rev.Custom == nil {
+		return err
+	}
+	switch rev.Custom.Sig() {
+{{- range .Errors}}
+	case "{{.Sig}}":
+		res := &{{.GoName}}{}
+		if rev.Custom.Inputs.CopyEach(rev.Args{{range .Fields}}, &res.{{.Name}}{{end}}) != nil {
+			return err
+		}
+		return res
+{{- end}}
+	}
+	return err
+}
+{{- end}}
+`
@@ -0,0 +1,120 @@
+package ethrpc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ModChain/ethrpc/abi"
+)
+
+// Contract allows calling methods of a deployed contract through an [Api]
+type Contract struct {
+	Address abi.Address
+	ABI     *abi.ABI
+	Api     *Api
+
+	CCIPRead *CCIPRead // if set, calls follow EIP-3668 offchain lookups
+}
+
+// NewContract returns a new [Contract] for the given address and abi
+func NewContract(addr abi.Address, a *abi.ABI, api *Api) *Contract {
+	return &Contract{Address: addr, ABI: a, Api: api}
+}
+
+// Method returns the abi method matching the given name or signature and number of arguments
+func (c *Contract) Method(name string, argc int) (*abi.Method, error) {
+	for _, m := range c.ABI.Methods {
+		if (m.Name == name || m.Sig() == name) && len(m.Inputs) == argc {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s with %d arguments", ErrMethodNotFound, name, argc)
+}
+
+// Pack returns the calldata for a call to the given method
+func (c *Contract) Pack(method string, args ...any) ([]byte, error) {
+	m, err := c.Method(method, len(args))
+	if err != nil {
+		return nil, err
+	}
+	return m.Pack(args...)
+}
+
+// CallRaw performs eth_call for the given method and returns the undecoded result
+func (c *Contract) CallRaw(ctx context.Context, method string, args ...any) ([]byte, error) {
+	data, err := c.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	msg := &CallMsg{To: &c.Address, Data: data}
+	var res []byte
+	if c.CCIPRead != nil {
+		res, err = c.Api.CallCCIP(ctx, msg, "latest", c.CCIPRead)
+	} else {
+		res, err = c.Api.Call(ctx, msg, "latest")
+	}
+	if err != nil {
+		return nil, decodeRevert(err, c.ABI)
+	}
+	return res, nil
+}
+
+// Call performs eth_call for the given method and returns the decoded values
+func (c *Contract) Call(ctx context.Context, method string, args ...any) ([]any, error) {
+	m, err := c.Method(method, len(args))
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.CallRaw(ctx, m.Sig(), args...)
+	if err != nil {
+		return nil, err
+	}
+	return m.Unpack(res)
+}
+
+// CallTo performs eth_call for the given method and decodes the result into target, which can
+// point to a value of the returned type if there is a single return value, or to a struct.
+func (c *Contract) CallTo(ctx context.Context, target any, method string, args ...any) error {
+	m, err := c.Method(method, len(args))
+	if err != nil {
+		return err
+	}
+	res, err := c.CallRaw(ctx, m.Sig(), args...)
+	if err != nil {
+		return err
+	}
+	return m.UnpackInto(target, res)
+}
+
+// Estimate returns the gas needed to call the given method from the given address
+func (c *Contract) Estimate(ctx context.Context, from abi.Address, method string, args ...any) (uint64, error) {
+	data, err := c.Pack(method, args...)
+	if err != nil {
+		return 0, err
+	}
+	res, err := c.Api.EstimateGas(ctx, &CallMsg{From: &from, To: &c.Address, Data: data})
+	if err != nil {
+		return 0, decodeRevert(err, c.ABI)
+	}
+	return res, nil
+}
+
+// Transact builds a transaction calling the given method, signs it with signer and sends it. The
+// transaction hash is returned.
+func (c *Contract) Transact(ctx context.Context, signer Signer, method string, args ...any) (string, error) {
+	return c.TransactValue(ctx, signer, nil, method, args...)
+}
+
+// TransactValue is the same as Transact but also sends value wei to the contract
+func (c *Contract) TransactValue(ctx context.Context, signer Signer, value *big.Int, method string, args ...any) (string, error) {
+	data, err := c.Pack(method, args...)
+	if err != nil {
+		return "", err
+	}
+	tx, err := c.Api.PrepareTransaction(ctx, signer.Address(), &c.Address, value, data)
+	if err != nil {
+		return "", decodeRevert(err, c.ABI)
+	}
+	return c.Api.SendTransaction(ctx, signer, tx)
+}
@@ -1,10 +1,12 @@
 package ethrpc
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"math/big"
 	"strconv"
+	"strings"
 )
 
 // ReadUint64 decodes the return value and passes it as a uint64.
@@ -67,6 +69,15 @@ func ReadString(v json.RawMessage, e error) (string, error) {
 	return v2, err
 }
 
+// ReadBytes decodes a hex-encoded return value such as the result of eth_call
+func ReadBytes(v json.RawMessage, e error) ([]byte, error) {
+	s, err := ReadString(v, e)
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(strings.TrimPrefix(s, "0x"))
+}
+
 // ReadTo returns a setter function that will return an error if an error happens. This is
 // a bit convoluted because of limitation in Go's syntax, but this could be used as:
 //
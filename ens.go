@@ -0,0 +1,257 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/ModChain/ethrpc/abi"
+	"github.com/ModChain/ethrpc/chains"
+)
+
+var (
+	ensRegistryABI = abi.MustParseHuman(
+		"function resolver(bytes32 node) view returns (address)",
+	)
+	ensResolverABI = abi.MustParseHuman(
+		"function supportsInterface(bytes4 interfaceID) view returns (bool)",
+		"function addr(bytes32 node) view returns (address)",
+		"function name(bytes32 node) view returns (string)",
+		"function text(bytes32 node, string key) view returns (string)",
+		"function contenthash(bytes32 node) view returns (bytes)",
+		"function resolve(bytes name, bytes data) view returns (bytes)",
+	)
+
+	// ENSIP-10 IExtendedResolver interface id
+	ensExtendedResolver = [4]byte{0x90, 0x61, 0xb9, 0x23}
+)
+
+// Namehash returns the ENS namehash of the given name. Names are lowercased but no other
+// normalization is performed.
+func Namehash(name string) abi.Hash {
+	var node abi.Hash
+	if name == "" {
+		return node
+	}
+	labels := strings.Split(strings.ToLower(name), ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		node = abi.Keccak256Hash(node[:], abi.Keccak256([]byte(labels[i])))
+	}
+	return node
+}
+
+// DNSEncode returns the DNS wire format of the given name, as used by ENSIP-10 resolvers
+func DNSEncode(name string) ([]byte, error) {
+	var res []byte
+	if name != "" {
+		for _, label := range strings.Split(strings.ToLower(name), ".") {
+			if len(label) == 0 || len(label) > 255 {
+				return nil, fmt.Errorf("invalid ens name %q", name)
+			}
+			res = append(res, byte(len(label)))
+			res = append(res, label...)
+		}
+	}
+	return append(res, 0), nil
+}
+
+// ENS resolves ENS names with custom settings. The resolver of a name is chosen by its owner,
+// and offchain resolvers make the client query the gateway URLs they return (EIP-3668). When
+// resolving names received from untrusted users on a server, set CCIPRead.AllowedURLs or use a
+// HTTPClient that cannot reach internal services, or leave CCIPRead nil to disable offchain
+// lookups altogether.
+type ENS struct {
+	Api      *Api
+	CCIPRead *CCIPRead // settings of offchain lookups, which are disabled if nil
+}
+
+// ens returns the [ENS] used by the methods of [Api], which allow offchain lookups to any url
+func (a *Api) ens() *ENS {
+	return &ENS{Api: a, CCIPRead: &CCIPRead{}}
+}
+
+// ensResolver is the resolver found for a given name
+type ensResolver struct {
+	contract *Contract
+	name     string
+	node     abi.Hash
+	extended bool // resolver supports ENSIP-10 resolve()
+}
+
+// ENSRegistry returns the address of the ENS registry on the connected chain
+func (a *Api) ENSRegistry(ctx context.Context) (abi.Address, error) {
+	id, err := a.ChainId(ctx)
+	if err != nil {
+		return abi.Address{}, err
+	}
+	info := chains.Get(id)
+	if info == nil || info.ENS == nil || info.ENS.Registry == "" {
+		return abi.Address{}, fmt.Errorf("%w (chain %d)", ErrENSNotSupported, id)
+	}
+	return abi.ParseAddress(info.ENS.Registry)
+}
+
+// ENSResolver returns the address of the resolver for the given name, see [ENS.Resolver]
+func (a *Api) ENSResolver(ctx context.Context, name string) (abi.Address, error) {
+	return a.ens().Resolver(ctx, name)
+}
+
+// Resolver returns the address of the resolver for the given name. Following ENSIP-10, if the
+// name has no resolver the resolver of the closest parent is used, provided it supports
+// wildcard resolution.
+func (e *ENS) Resolver(ctx context.Context, name string) (abi.Address, error) {
+	r, err := e.resolver(ctx, name)
+	if err != nil {
+		return abi.Address{}, err
+	}
+	return r.contract.Address, nil
+}
+
+func (e *ENS) resolver(ctx context.Context, name string) (*ensResolver, error) {
+	registryAddr, err := e.Api.ENSRegistry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	registry := NewContract(registryAddr, ensRegistryABI, e.Api)
+
+	for cur := name; ; {
+		var addr abi.Address
+		if err := registry.CallTo(ctx, &addr, "resolver", Namehash(cur)); err != nil {
+			return nil, err
+		}
+		if addr != (abi.Address{}) {
+			r := &ensResolver{
+				contract: NewContract(addr, ensResolverABI, e.Api),
+				name:     name,
+				node:     Namehash(name),
+			}
+			// offchain resolvers rely on CCIP-Read
+			r.contract.CCIPRead = e.CCIPRead
+			var ok bool
+			if err := r.contract.CallTo(ctx, &ok, "supportsInterface", ensExtendedResolver); err != nil && !isRevert(err) {
+				return nil, err
+			}
+			r.extended = ok
+			if cur != name && !r.extended {
+				// parent resolver does not support wildcards
+				break
+			}
+			return r, nil
+		}
+		pos := strings.IndexByte(cur, '.')
+		if pos == -1 {
+			break
+		}
+		cur = cur[pos+1:]
+	}
+	return nil, fmt.Errorf("%w: no resolver for %s", ErrENSNotFound, name)
+}
+
+// call calls the given method on the resolver, using resolve() for extended resolvers
+func (r *ensResolver) call(ctx context.Context, target any, method string, args ...any) error {
+	if !r.extended {
+		return r.contract.CallTo(ctx, target, method, args...)
+	}
+	m, err := r.contract.Method(method, len(args))
+	if err != nil {
+		return err
+	}
+	data, err := m.Pack(args...)
+	if err != nil {
+		return err
+	}
+	dns, err := DNSEncode(r.name)
+	if err != nil {
+		return err
+	}
+	var res []byte
+	if err := r.contract.CallTo(ctx, &res, "resolve", dns, data); err != nil {
+		return err
+	}
+	return m.UnpackInto(target, res)
+}
+
+// ResolveName returns the address the given ENS name points to. Offchain resolvers may query
+// any gateway url, see [ENS] to restrict them.
+func (a *Api) ResolveName(ctx context.Context, name string) (abi.Address, error) {
+	return a.ens().ResolveName(ctx, name)
+}
+
+// LookupAddress returns the primary ENS name of the given address, see [ENS.LookupAddress]
+func (a *Api) LookupAddress(ctx context.Context, addr abi.Address) (string, error) {
+	return a.ens().LookupAddress(ctx, addr)
+}
+
+// ENSText returns the text record with the given key (such as "url" or "avatar") for name
+func (a *Api) ENSText(ctx context.Context, name, key string) (string, error) {
+	return a.ens().Text(ctx, name, key)
+}
+
+// ENSContentHash returns the raw EIP-1577 contenthash record of name
+func (a *Api) ENSContentHash(ctx context.Context, name string) ([]byte, error) {
+	return a.ens().ContentHash(ctx, name)
+}
+
+// ResolveName returns the address the given ENS name points to
+func (e *ENS) ResolveName(ctx context.Context, name string) (abi.Address, error) {
+	var res abi.Address
+	r, err := e.resolver(ctx, name)
+	if err != nil {
+		return res, err
+	}
+	if err := r.call(ctx, &res, "addr", r.node); err != nil {
+		return res, err
+	}
+	if res == (abi.Address{}) {
+		return res, fmt.Errorf("%w: %s has no address", ErrENSNotFound, name)
+	}
+	return res, nil
+}
+
+// LookupAddress returns the primary ENS name of the given address. The name is only returned
+// if it resolves back to the same address.
+func (e *ENS) LookupAddress(ctx context.Context, addr abi.Address) (string, error) {
+	reverse := hex.EncodeToString(addr[:]) + ".addr.reverse"
+	r, err := e.resolver(ctx, reverse)
+	if err != nil {
+		return "", err
+	}
+	var name string
+	if err := r.call(ctx, &name, "name", r.node); err != nil {
+		return "", err
+	}
+	if name == "" {
+		return "", fmt.Errorf("%w: no reverse record for %s", ErrENSNotFound, addr)
+	}
+	fwd, err := e.ResolveName(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	if fwd != addr {
+		return "", fmt.Errorf("%w: %s does not resolve to %s", ErrENSNotFound, name, addr)
+	}
+	return name, nil
+}
+
+// Text returns the text record with the given key (such as "url" or "avatar") for name
+func (e *ENS) Text(ctx context.Context, name, key string) (string, error) {
+	r, err := e.resolver(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	var res string
+	err = r.call(ctx, &res, "text", r.node, key)
+	return res, err
+}
+
+// ContentHash returns the raw EIP-1577 contenthash record of name
+func (e *ENS) ContentHash(ctx context.Context, name string) ([]byte, error) {
+	r, err := e.resolver(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	var res []byte
+	err = r.call(ctx, &res, "contenthash", r.node)
+	return res, err
+}
@@ -0,0 +1,197 @@
+package ethrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ModChain/ethrpc/abi"
+	"github.com/ModChain/ethrpc/chains"
+)
+
+var (
+	ensTestResolver  = abi.MustParseAddress("0x00000000000000000000000000000000000e2501")
+	ensTestWildcard  = abi.MustParseAddress("0x00000000000000000000000000000000000e2502")
+	ensTestCallback  = [4]byte{0x33, 0x33, 0x33, 0x33}
+	ensTestAlice     = abi.MustParseAddress("0x000000000000000000000000000000000000a11c")
+	ensTestBob       = abi.MustParseAddress("0x0000000000000000000000000000000000000b0b")
+	ensTestAliceName = hex.EncodeToString(ensTestAlice[:]) + ".addr.reverse"
+)
+
+// ensTestNode returns a mainnet handler with two resolvers: ensTestResolver for alice.eth and the
+// reverse record of alice, and ensTestWildcard, an offchain resolver for offchain.eth and its
+// subnames that reverts with OffchainLookup to the given gateway
+func ensTestNode(t *testing.T, gateway string) RequestHandlerFunc {
+	registry := abi.MustParseAddress(chains.Get(1).ENS.Registry)
+	resolvers := map[abi.Hash]abi.Address{
+		Namehash("alice.eth"):      ensTestResolver,
+		Namehash(ensTestAliceName): ensTestResolver,
+		Namehash("offchain.eth"):   ensTestWildcard,
+	}
+	pack := func(args abi.Arguments, values ...any) (json.RawMessage, error) {
+		buf, err := args.Pack(values...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return json.Marshal("0x" + hex.EncodeToString(buf))
+	}
+	resolver := func(name string) *abi.Method { return ensResolverABI.Method(name) }
+
+	return func(ctx context.Context, req *Request) (json.RawMessage, error) {
+		if req.Method == "eth_chainId" {
+			return json.RawMessage(`"0x1"`), nil
+		}
+		msg := req.Params.([]any)[0].(*CallMsg)
+		sel, data := msg.Data[:4], msg.Data[4:]
+		var args []any
+		for _, m := range []*abi.Method{ensRegistryABI.Method("resolver"), resolver("supportsInterface"), resolver("addr"), resolver("name"), resolver("text"), resolver("contenthash"), resolver("resolve")} {
+			if bytes.Equal(sel, m.Selector()) {
+				args, _ = m.UnpackInputs(msg.Data)
+			}
+		}
+
+		switch {
+		case *msg.To == registry && bytes.Equal(sel, ensRegistryABI.Method("resolver").Selector()):
+			return pack(ensRegistryABI.Method("resolver").Outputs, resolvers[abi.Hash(args[0].([]byte))])
+		case bytes.Equal(sel, resolver("supportsInterface").Selector()):
+			return pack(resolver("supportsInterface").Outputs, *msg.To == ensTestWildcard && [4]byte(args[0].([]byte)) == ensExtendedResolver)
+		case *msg.To == ensTestResolver && bytes.Equal(sel, resolver("addr").Selector()):
+			if abi.Hash(args[0].([]byte)) == Namehash("alice.eth") {
+				return pack(resolver("addr").Outputs, ensTestAlice)
+			}
+			return pack(resolver("addr").Outputs, abi.Address{})
+		case *msg.To == ensTestResolver && bytes.Equal(sel, resolver("name").Selector()):
+			return pack(resolver("name").Outputs, "alice.eth")
+		case *msg.To == ensTestResolver && bytes.Equal(sel, resolver("text").Selector()):
+			return pack(resolver("text").Outputs, "value of "+args[1].(string))
+		case *msg.To == ensTestResolver && bytes.Equal(sel, resolver("contenthash").Selector()):
+			return pack(resolver("contenthash").Outputs, []byte{0xe3, 0x01})
+		case *msg.To == ensTestWildcard && bytes.Equal(sel, resolver("resolve").Selector()):
+			revert, err := offchainLookupError.Inputs.Pack(ensTestWildcard, []string{gateway + "/{sender}/{data}.json"}, msg.Data, ensTestCallback, []byte{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return nil, &ErrorObject{Code: 3, Message: "execution reverted", Data: "0x" + hex.EncodeToString(append(offchainLookupError.Selector(), revert...))}
+		case *msg.To == ensTestWildcard && bytes.Equal(sel, ensTestCallback[:]):
+			res, err := ccipCallbackArgs.Unpack(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return pack(resolver("resolve").Outputs, res[0])
+		}
+		t.Errorf("unexpected call to %s: %x", msg.To, msg.Data)
+		return nil, &ErrorObject{Code: 3, Message: "execution reverted"}
+	}
+}
+
+// ensTestGateway returns a gateway answering every lookup with the address of bob
+func ensTestGateway(t *testing.T, hits *atomic.Int64) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		buf, _ := ensResolverABI.Method("addr").Outputs.Pack(ensTestBob)
+		json.NewEncoder(rw).Encode(map[string]string{"data": "0x" + hex.EncodeToString(buf)})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestNamehash(t *testing.T) {
+	tests := map[string]string{
+		"":            "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"eth":         "0x93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae",
+		"vitalik.eth": "0xee6c4522aab0003e8d14cd40a6af439055fd2577951148c14b6cea9a53475835",
+		"VITALIK.eth": "0xee6c4522aab0003e8d14cd40a6af439055fd2577951148c14b6cea9a53475835",
+	}
+	for name, want := range tests {
+		if got := Namehash(name); got.Hex() != want {
+			t.Errorf("Namehash(%q) = %s, want %s", name, got.Hex(), want)
+		}
+	}
+}
+
+func TestDNSEncode(t *testing.T) {
+	tests := []struct {
+		name string
+		want []byte
+		err  bool
+	}{
+		{"", []byte{0}, false},
+		{"eth", []byte("\x03eth\x00"), false},
+		{"Foo.eth", []byte("\x03foo\x03eth\x00"), false},
+		{"foo..eth", nil, true},
+	}
+	for _, test := range tests {
+		res, err := DNSEncode(test.name)
+		if (err != nil) != test.err || !bytes.Equal(res, test.want) {
+			t.Errorf("DNSEncode(%q) = %q, %v", test.name, res, err)
+		}
+	}
+}
+
+func TestENS(t *testing.T) {
+	var hits atomic.Int64
+	gw := ensTestGateway(t, &hits)
+	api := &Api{ensTestNode(t, gw.URL)}
+	ctx := context.Background()
+
+	if res, err := api.ResolveName(ctx, "alice.eth"); err != nil || res != ensTestAlice {
+		t.Errorf("ResolveName(alice.eth) = %s, %v", res, err)
+	}
+	if res, err := api.ENSResolver(ctx, "alice.eth"); err != nil || res != ensTestResolver {
+		t.Errorf("ENSResolver(alice.eth) = %s, %v", res, err)
+	}
+	if res, err := api.LookupAddress(ctx, ensTestAlice); err != nil || res != "alice.eth" {
+		t.Errorf("LookupAddress(alice) = %q, %v", res, err)
+	}
+	if res, err := api.ENSText(ctx, "alice.eth", "url"); err != nil || res != "value of url" {
+		t.Errorf("ENSText(alice.eth, url) = %q, %v", res, err)
+	}
+	if res, err := api.ENSContentHash(ctx, "alice.eth"); err != nil || !bytes.Equal(res, []byte{0xe3, 0x01}) {
+		t.Errorf("ENSContentHash(alice.eth) = %x, %v", res, err)
+	}
+	for _, name := range []string{"nobody.eth", "sub.alice.eth"} {
+		if _, err := api.ResolveName(ctx, name); !errors.Is(err, ErrENSNotFound) {
+			t.Errorf("ResolveName(%s) returned %v, want ErrENSNotFound", name, err)
+		}
+	}
+	if _, err := api.LookupAddress(ctx, ensTestBob); !errors.Is(err, ErrENSNotFound) {
+		t.Errorf("LookupAddress(bob) returned %v, want ErrENSNotFound", err)
+	}
+}
+
+func TestENSOffchain(t *testing.T) {
+	var hits atomic.Int64
+	gw := ensTestGateway(t, &hits)
+	node := ensTestNode(t, gw.URL)
+
+	tests := []struct {
+		name string
+		ens  *ENS
+		hits int64
+		err  bool
+	}{
+		{"default", (&Api{node}).ens(), 1, false},
+		{"allowed", &ENS{Api: &Api{node}, CCIPRead: &CCIPRead{AllowedURLs: []string{gw.URL}}}, 1, false},
+		{"not allowed", &ENS{Api: &Api{node}, CCIPRead: &CCIPRead{AllowedURLs: []string{"https://gateway.example.com"}}}, 0, true},
+		{"disabled", &ENS{Api: &Api{node}}, 0, true},
+	}
+	for _, test := range tests {
+		hits.Store(0)
+		res, err := test.ens.ResolveName(context.Background(), "bob.offchain.eth")
+		switch {
+		case test.err && err == nil:
+			t.Errorf("%s: resolved to %s, expected an error", test.name, res)
+		case !test.err && (err != nil || res != ensTestBob):
+			t.Errorf("%s: got %s, %v", test.name, res, err)
+		}
+		if n := hits.Load(); n != test.hits {
+			t.Errorf("%s: gateway was queried %d times, want %d", test.name, n, test.hits)
+		}
+	}
+}
@@ -3,5 +3,20 @@ package ethrpc
 import "errors"
 
 var (
-	ErrNoAvailableServer = errors.New("no available server")
+	ErrNoAvailableServer     = errors.New("no available server")
+	ErrFeeCeiling            = errors.New("replacement fees would exceed the configured ceiling")
+	ErrInvalidSignature      = errors.New("invalid signature")
+	ErrInvalidKey            = errors.New("invalid private key")
+	ErrInvalidHashLength     = errors.New("hash must be 32 bytes")
+	ErrInvalidResponse       = errors.New("invalid response from server")
+	ErrUnknownEvent          = errors.New("log does not match any known event")
+	ErrReorgTooDeep          = errors.New("chain reorganization deeper than the tracked blocks")
+	ErrMethodNotFound        = errors.New("method not found in abi")
+	ErrENSNotSupported       = errors.New("ens is not available on this chain")
+	ErrENSNotFound           = errors.New("ens name not found")
+	ErrOffchainLookup        = errors.New("offchain lookup failed")
+	ErrInvalidTraceParent    = errors.New("invalid traceparent header")
+	ErrRateLimited           = errors.New("rate limit exceeded")
+	ErrMulticallNotSupported = errors.New("multicall3 is not available on this chain")
+	ErrMissingFees           = errors.New("transaction has no fees to bump")
 )
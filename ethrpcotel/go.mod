@@ -0,0 +1,20 @@
+module github.com/ModChain/ethrpc/ethrpcotel
+
+go 1.22.2
+
+require (
+	github.com/ModChain/ethrpc v0.0.0-00010101000000-000000000000
+	go.opentelemetry.io/otel v1.31.0
+	go.opentelemetry.io/otel/trace v1.31.0
+)
+
+require (
+	github.com/KarpelesLab/pjson v0.1.7 // indirect
+	github.com/KarpelesLab/typutil v0.2.26 // indirect
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.3.0 // indirect
+	golang.org/x/crypto v0.31.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+)
+
+// the tracing hooks are not in a tagged release of ethrpc yet
+replace github.com/ModChain/ethrpc => ../
@@ -0,0 +1,95 @@
+// Package ethrpcotel adapts OpenTelemetry tracers to [ethrpc.Tracer], so that json-rpc requests
+// are recorded as OpenTelemetry spans.
+package ethrpcotel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ModChain/ethrpc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type tracer struct {
+	t    trace.Tracer
+	opts []trace.SpanStartOption
+}
+
+type span struct {
+	s trace.Span
+}
+
+// NewTracer returns a [ethrpc.Tracer] starting spans with t. Spans are client spans unless
+// another kind is passed in opts, as for a [ethrpc.Proxy]:
+//
+//	proxy.Use(ethrpc.TracingMiddleware(ethrpcotel.NewTracer(t, trace.WithSpanKind(trace.SpanKindServer)), "proxy"))
+func NewTracer(t trace.Tracer, opts ...trace.SpanStartOption) ethrpc.Tracer {
+	return &tracer{t: t, opts: append([]trace.SpanStartOption{trace.WithSpanKind(trace.SpanKindClient)}, opts...)}
+}
+
+// Start starts a span, using the remote span context extracted by [ethrpc.Proxy] as parent if
+// ctx holds no OpenTelemetry span
+func (t *tracer) Start(ctx context.Context, name string) (context.Context, ethrpc.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		if sc, ok := ethrpc.SpanContextFromContext(ctx); ok {
+			ctx = trace.ContextWithRemoteSpanContext(ctx, otelSpanContext(sc))
+		}
+	}
+	ctx, s := t.t.Start(ctx, name, t.opts...)
+	return ctx, &span{s}
+}
+
+func (s *span) SetAttribute(key string, value any) {
+	var kv attribute.KeyValue
+	switch v := value.(type) {
+	case string:
+		kv = attribute.String(key, v)
+	case int:
+		kv = attribute.Int(key, v)
+	case int64:
+		kv = attribute.Int64(key, v)
+	case uint64:
+		kv = attribute.Int64(key, int64(v))
+	case float64:
+		kv = attribute.Float64(key, v)
+	case bool:
+		kv = attribute.Bool(key, v)
+	default:
+		kv = attribute.String(key, fmt.Sprint(v))
+	}
+	s.s.SetAttributes(kv)
+}
+
+func (s *span) RecordError(err error) {
+	s.s.RecordError(err)
+	s.s.SetStatus(codes.Error, err.Error())
+}
+
+func (s *span) End() {
+	s.s.End()
+}
+
+func (s *span) SpanContext() ethrpc.SpanContext {
+	sc := s.s.SpanContext()
+	return ethrpc.SpanContext{
+		TraceId:    sc.TraceID(),
+		SpanId:     sc.SpanID(),
+		Flags:      byte(sc.TraceFlags()),
+		TraceState: sc.TraceState().String(),
+		Remote:     sc.IsRemote(),
+	}
+}
+
+// otelSpanContext converts a [ethrpc.SpanContext] to its OpenTelemetry equivalent
+func otelSpanContext(sc ethrpc.SpanContext) trace.SpanContext {
+	state, _ := trace.ParseTraceState(sc.TraceState)
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    sc.TraceId,
+		SpanID:     sc.SpanId,
+		TraceFlags: trace.TraceFlags(sc.Flags),
+		TraceState: state,
+		Remote:     sc.Remote,
+	})
+}
@@ -1,16 +1,71 @@
 package ethrpc
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
+	"slices"
 	"time"
 )
 
 type RPCList []*RPC
 
+// DoCtx sends a request to the first server of the list that can take it. If all servers are
+// throttled or out of tokens, it waits for the one available the soonest, and returns
+// [ErrRateLimited] without waiting if ctx expires before that. Use a ctx with a deadline to
+// fail fast.
 func (r RPCList) DoCtx(ctx context.Context, method string, args ...any) (json.RawMessage, error) {
-	// TODO might want to be able to fallback to the next server in list or other fancy things...
-	return r[0].DoCtx(ctx, method, args...)
+	if method == "eth_sendRawTransaction" && len(r) > 1 && len(args) == 1 {
+		// transactions are pushed to all servers at once
+		if rawTx, ok := args[0].(string); ok {
+			return r.broadcastRaw(ctx, rawTx)
+		}
+	}
+	return r.send(ctx, NewRequest(method, args...))
+}
+
+// SendCtx sends a raw request, see DoCtx
+func (r RPCList) SendCtx(ctx context.Context, req *Request) (json.RawMessage, error) {
+	if params, ok := req.Params.([]any); ok {
+		return r.DoCtx(ctx, req.Method, params...)
+	}
+	return r.send(ctx, req)
+}
+
+// Use adds middlewares to each server of the list, see [RPC.Use]. Requests going through several
+// servers, such as broadcasts or retries after rate limits, pass through the middlewares of each
+// of them. Use [Chain] to process requests once for the whole list.
+func (r RPCList) Use(mw ...Middleware) {
+	for _, s := range r {
+		s.Use(mw...)
+	}
+}
+
+// send sends req to the first server that can take it without waiting, moving on to the next
+// server if it is refused because of rate limits. Servers that are throttled or out of tokens
+// are only used once the others have failed.
+func (r RPCList) send(ctx context.Context, req *Request) (json.RawMessage, error) {
+	if len(r) == 0 {
+		return nil, ErrNoAvailableServer
+	}
+	servers := slices.Clone(r)
+	delays := make(map[*RPC]time.Duration, len(servers))
+	for _, s := range servers {
+		delays[s] = s.Delay(req.Method)
+	}
+	slices.SortStableFunc(servers, func(a, b *RPC) int {
+		return cmp.Compare(delays[a], delays[b])
+	})
+
+	var err error
+	for _, s := range servers {
+		var res json.RawMessage
+		res, err = s.SendCtx(ctx, req)
+		if err == nil || ctx.Err() != nil || !(isRateLimited(err) || time.Now().Before(s.ThrottledUntil())) {
+			return res, err
+		}
+	}
+	return nil, err
 }
 
 // Evaluate will call the various servers in the list and return a list of servers that work (if any)
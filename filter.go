@@ -0,0 +1,154 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ModChain/ethrpc/abi"
+)
+
+// FilterQuery holds the parameters of eth_getLogs and eth_newFilter
+type FilterQuery struct {
+	FromBlock string // block number (see BlockTag) or tag such as "latest", omitted if empty
+	ToBlock   string
+	BlockHash *abi.Hash // if set, FromBlock and ToBlock must be empty
+	Addresses []abi.Address
+	Topics    [][]abi.Hash // each position is a list of alternatives, nil matching anything
+}
+
+// BlockTag returns the given block number in the format expected by the RPC server
+func BlockTag(n uint64) string {
+	return hexUint(n)
+}
+
+// NewFilterQuery returns a query for logs emitted by the given addresses (or any address if none)
+func NewFilterQuery(addrs ...abi.Address) *FilterQuery {
+	return &FilterQuery{Addresses: addrs}
+}
+
+// Range sets the range of blocks the query applies to, inclusive
+func (q *FilterQuery) Range(from, to uint64) *FilterQuery {
+	q.FromBlock = BlockTag(from)
+	q.ToBlock = BlockTag(to)
+	return q
+}
+
+// WithEvent sets the topics of the query to match logs of the given event, with optional values
+// for its indexed parameters. See [abi.Event.Topics] for the accepted values.
+func (q *FilterQuery) WithEvent(ev *abi.Event, values ...any) (*FilterQuery, error) {
+	topics, err := ev.Topics(values...)
+	if err != nil {
+		return nil, err
+	}
+	q.Topics = topics
+	return q, nil
+}
+
+// MustWithEvent is like WithEvent but panics if the values do not match the event
+func (q *FilterQuery) MustWithEvent(ev *abi.Event, values ...any) *FilterQuery {
+	res, err := q.WithEvent(ev, values...)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// WithEvents sets the topics of the query to match logs of any of the given events
+func (q *FilterQuery) WithEvents(evs ...*abi.Event) *FilterQuery {
+	ids := make([]abi.Hash, 0, len(evs))
+	for _, ev := range evs {
+		ids = append(ids, ev.ID())
+	}
+	q.Topics = [][]abi.Hash{ids}
+	return q
+}
+
+// MarshalJSON encodes the query as expected by the RPC server
+func (q *FilterQuery) MarshalJSON() ([]byte, error) {
+	res := make(map[string]any)
+	if q.FromBlock != "" {
+		res["fromBlock"] = q.FromBlock
+	}
+	if q.ToBlock != "" {
+		res["toBlock"] = q.ToBlock
+	}
+	if q.BlockHash != nil {
+		res["blockHash"] = q.BlockHash
+	}
+	switch len(q.Addresses) {
+	case 0:
+	case 1:
+		res["address"] = q.Addresses[0]
+	default:
+		res["address"] = q.Addresses
+	}
+	if len(q.Topics) > 0 {
+		topics := make([]any, len(q.Topics))
+		for n, pos := range q.Topics {
+			switch len(pos) {
+			case 0:
+				// nil, matches anything
+			case 1:
+				topics[n] = pos[0]
+			default:
+				topics[n] = pos
+			}
+		}
+		res["topics"] = topics
+	}
+	return json.Marshal(res)
+}
+
+// GetLogs returns the logs matching the given query
+func (a *Api) GetLogs(ctx context.Context, q *FilterQuery) ([]*Log, error) {
+	return ReadAs[[]*Log](a.Handler.DoCtx(ctx, "eth_getLogs", q))
+}
+
+// EventDecoder decodes logs by matching their first topic against a set of events
+type EventDecoder struct {
+	events map[abi.Hash]*abi.Event
+}
+
+// NewEventDecoder returns a decoder for the given events, such as the Events of an [abi.ABI].
+// Anonymous events cannot be matched and are ignored.
+func NewEventDecoder(events ...*abi.Event) *EventDecoder {
+	d := &EventDecoder{events: make(map[abi.Hash]*abi.Event)}
+	for _, ev := range events {
+		if !ev.Anonymous {
+			d.events[ev.ID()] = ev
+		}
+	}
+	return d
+}
+
+// Match returns the event matching the given log, or nil
+func (d *EventDecoder) Match(l *Log) *abi.Event {
+	if len(l.Topics) == 0 {
+		return nil
+	}
+	return d.events[l.Topics[0]]
+}
+
+// Decode decodes the given log and returns the matching event and its values by name.
+// Indexed strings, bytes, arrays and tuples are returned as the hash of their value.
+func (d *EventDecoder) Decode(l *Log) (*abi.Event, map[string]any, error) {
+	ev := d.Match(l)
+	if ev == nil {
+		return nil, nil, ErrUnknownEvent
+	}
+	res, err := ev.DecodeLogMap(l.Topics, l.Data)
+	if err != nil {
+		return ev, nil, err
+	}
+	return ev, res, nil
+}
+
+// DecodeInto decodes the given log into target, which is typically a pointer to a struct with
+// fields named after the event parameters, and returns the matching event.
+func (d *EventDecoder) DecodeInto(l *Log, target any) (*abi.Event, error) {
+	ev := d.Match(l)
+	if ev == nil {
+		return nil, ErrUnknownEvent
+	}
+	return ev, ev.DecodeLogInto(target, l.Topics, l.Data)
+}
@@ -0,0 +1,112 @@
+package ethrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ModChain/ethrpc/abi"
+)
+
+var filterTestABI = abi.MustParseHuman(
+	"event Transfer(address indexed from, address indexed to, uint256 value)",
+	"event Approval(address indexed owner, address indexed spender, uint256 value)",
+)
+
+const (
+	transferTopic = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+	approvalTopic = "0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925"
+)
+
+func TestFilterQueryJSON(t *testing.T) {
+	token := abi.MustParseAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+	to := abi.MustParseAddress("0x00000000000000000000000000000000000000aa")
+	other := abi.MustParseAddress("0x00000000000000000000000000000000000000bb")
+	toTopic := "0x00000000000000000000000000000000000000000000000000000000000000aa"
+	otherTopic := "0x00000000000000000000000000000000000000000000000000000000000000bb"
+	transfer := filterTestABI.Event("Transfer")
+
+	tests := []struct {
+		name string
+		q    *FilterQuery
+		want string
+	}{
+		{"empty", NewFilterQuery(), `{}`},
+		{"range", NewFilterQuery(token).Range(1, 16), `{"address":"` + token.Hex() + `","fromBlock":"0x1","toBlock":"0x10"}`},
+		{"addresses", NewFilterQuery(token, to), `{"address":["` + token.Hex() + `","` + to.Hex() + `"]}`},
+		{"event", NewFilterQuery().MustWithEvent(transfer), `{"topics":["` + transferTopic + `"]}`},
+		{"indexed value", NewFilterQuery().MustWithEvent(transfer, nil, to), `{"topics":["` + transferTopic + `",null,"` + toTopic + `"]}`},
+		{"alternatives", NewFilterQuery().MustWithEvent(transfer, abi.OneOf{to, other}), `{"topics":["` + transferTopic + `",["` + toTopic + `","` + otherTopic + `"]]}`},
+		{"trailing wildcard", NewFilterQuery().MustWithEvent(transfer, to, nil), `{"topics":["` + transferTopic + `","` + toTopic + `"]}`},
+		{"events", NewFilterQuery().WithEvents(transfer, filterTestABI.Event("Approval")), `{"topics":[["` + transferTopic + `","` + approvalTopic + `"]]}`},
+	}
+	for _, test := range tests {
+		buf, err := json.Marshal(test.q)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if string(buf) != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, buf, test.want)
+		}
+	}
+
+	if _, err := NewFilterQuery().WithEvent(transfer, to, to, to); !errors.Is(err, abi.ErrInvalidValue) {
+		t.Errorf("too many values: got error %v, want ErrInvalidValue", err)
+	}
+}
+
+func TestEventDecoder(t *testing.T) {
+	const raw = `{
+		"address": "0x6b175474e89094c44da98b954eedeac495271d0f",
+		"topics": [
+			"` + transferTopic + `",
+			"0x00000000000000000000000000000000000000000000000000000000000000aa",
+			"0x00000000000000000000000000000000000000000000000000000000000000bb"
+		],
+		"data": "0x00000000000000000000000000000000000000000000000000000000000003e8",
+		"blockNumber": "0x10",
+		"blockHash": "0x1111111111111111111111111111111111111111111111111111111111111111",
+		"transactionHash": "0x2222222222222222222222222222222222222222222222222222222222222222",
+		"transactionIndex": "0x2",
+		"logIndex": "0x5",
+		"removed": false
+	}`
+	var l Log
+	if err := json.Unmarshal([]byte(raw), &l); err != nil {
+		t.Fatal(err)
+	}
+	if l.BlockNumber != 16 || l.TransactionIndex != 2 || l.LogIndex != 5 || len(l.Data) != 32 {
+		t.Errorf("log decoded as %+v", l)
+	}
+
+	d := NewEventDecoder(filterTestABI.Event("Approval"), filterTestABI.Event("Transfer"))
+	ev, values, err := d.Decode(&l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Name != "Transfer" {
+		t.Errorf("matched event %s", ev.Name)
+	}
+	if values["to"] != abi.MustParseAddress("0x00000000000000000000000000000000000000bb") || values["value"].(*big.Int).Int64() != 1000 {
+		t.Errorf("decoded %v", values)
+	}
+
+	var transfer struct {
+		From  abi.Address
+		To    abi.Address
+		Value *big.Int
+	}
+	if _, err := d.DecodeInto(&l, &transfer); err != nil {
+		t.Fatal(err)
+	}
+	if transfer.From != abi.MustParseAddress("0x00000000000000000000000000000000000000aa") || transfer.Value.Int64() != 1000 {
+		t.Errorf("decoded into %+v", transfer)
+	}
+
+	l.Topics[0] = abi.Hash{}
+	if _, _, err := d.Decode(&l); !errors.Is(err, ErrUnknownEvent) {
+		t.Errorf("unknown event: got error %v", err)
+	}
+}
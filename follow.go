@@ -0,0 +1,247 @@
+package ethrpc
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// BlockEventType tells if a block was added to or removed from the canonical chain
+type BlockEventType int
+
+const (
+	BlockAdded BlockEventType = iota
+	BlockRemoved
+)
+
+func (t BlockEventType) String() string {
+	if t == BlockRemoved {
+		return "removed"
+	}
+	return "added"
+}
+
+// BlockEvent is emitted by [ChainFollower] when the canonical chain changes
+type BlockEvent struct {
+	Type   BlockEventType
+	Header *BlockHeader
+}
+
+// ChainFollower tracks the head of the chain by polling, and emits ordered events as blocks are
+// added to or removed from the canonical chain. When a reorganization happens, removed blocks
+// are emitted from the most recent one, followed by the blocks of the new chain, so indexers can
+// roll back their state.
+type ChainFollower struct {
+	Events <-chan *BlockEvent
+
+	Interval time.Duration // how often to poll for new blocks, defaults to 4 seconds
+	Depth    int           // number of recent blocks kept to detect reorgs, including blocks before the start block, defaults to 128
+
+	api    *Api
+	events chan *BlockEvent
+	next   uint64
+	recent []*BlockHeader // ring buffer of the last blocks, oldest first
+	seeded bool           // true once the blocks before the start block are in recent
+	cancel func()
+	done   chan struct{}
+	start  sync.Once
+
+	lk  sync.Mutex
+	err error
+}
+
+// NewChainFollower returns a follower that will start at the given block and backfill up to the
+// head of the chain before following it. Use the result of BlockNumber to start at the head.
+// Fields can be changed before calling Start.
+func (a *Api) NewChainFollower(from uint64) *ChainFollower {
+	events := make(chan *BlockEvent)
+	return &ChainFollower{
+		Events:   events,
+		Interval: 4 * time.Second,
+		Depth:    128,
+		api:      a,
+		events:   events,
+		next:     from,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start starts following the chain in the background until ctx is cancelled, Close is called
+// or a reorganization deeper than Depth happens. Events is closed when following stops. Calling
+// Start again does nothing.
+func (f *ChainFollower) Start(ctx context.Context) {
+	f.start.Do(func() {
+		if f.Interval <= 0 {
+			f.Interval = 4 * time.Second
+		}
+		if f.Depth <= 0 {
+			f.Depth = 128
+		}
+		ctx, f.cancel = context.WithCancel(ctx)
+		go f.run(ctx)
+	})
+}
+
+// Close stops following the chain. It does nothing if Start was not called.
+func (f *ChainFollower) Close() {
+	if f.cancel == nil {
+		return
+	}
+	f.cancel()
+	<-f.done
+}
+
+// Err returns the error that stopped the follower, or the last polling error
+func (f *ChainFollower) Err() error {
+	f.lk.Lock()
+	defer f.lk.Unlock()
+	return f.err
+}
+
+func (f *ChainFollower) setErr(err error) {
+	f.lk.Lock()
+	defer f.lk.Unlock()
+	f.err = err
+}
+
+func (f *ChainFollower) run(ctx context.Context) {
+	defer close(f.done)
+	defer close(f.events)
+
+	t := time.NewTicker(f.Interval)
+	defer t.Stop()
+
+	for {
+		err := f.poll(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			f.setErr(err)
+			if errors.Is(err, ErrReorgTooDeep) {
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// poll catches up with the head of the chain
+func (f *ChainFollower) poll(ctx context.Context) error {
+	if !f.seeded {
+		if err := f.seed(ctx); err != nil || !f.seeded {
+			return err
+		}
+	}
+	head, err := f.api.BlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+
+	if head < f.next && len(f.recent) > 0 {
+		// no new block, but the chain may have been reorganized to a shorter one
+		top := f.recent[len(f.recent)-1]
+		h, err := f.api.HeaderByNumber(ctx, BlockTag(top.Number))
+		if err != nil {
+			return err
+		}
+		if h == nil || h.Hash != top.Hash {
+			if err := f.removeTop(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	for f.next <= head {
+		h, err := f.api.HeaderByNumber(ctx, BlockTag(f.next))
+		if err != nil {
+			return err
+		}
+		if h == nil {
+			// not available on this server yet
+			return nil
+		}
+
+		if len(f.recent) > 0 && h.ParentHash != f.recent[len(f.recent)-1].Hash {
+			// the block we have at this height is not the parent anymore, roll it back and
+			// check again one block lower
+			if err := f.removeTop(ctx); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := f.emit(ctx, &BlockEvent{Type: BlockAdded, Header: h}); err != nil {
+			return err
+		}
+		f.recent = append(f.recent, h)
+		if len(f.recent) > f.Depth {
+			f.recent = f.recent[len(f.recent)-f.Depth:]
+		}
+		f.next = h.Number + 1
+	}
+	return nil
+}
+
+// seed loads the blocks preceding the start block, so that a reorganization affecting them can
+// be rolled back
+func (f *ChainFollower) seed(ctx context.Context) error {
+	count := min(uint64(f.Depth-1), f.next)
+	if count == 0 {
+		f.seeded = true
+		return nil
+	}
+	h, err := f.api.HeaderByNumber(ctx, BlockTag(f.next-1))
+	if err != nil {
+		return err
+	}
+	if h == nil {
+		// the start block is ahead of this server, seed on the next poll
+		return nil
+	}
+	recent := []*BlockHeader{h}
+	for uint64(len(recent)) < count {
+		if h, err = f.api.HeaderByHash(ctx, h.ParentHash); err != nil {
+			return err
+		}
+		if h == nil {
+			break
+		}
+		recent = append(recent, h)
+	}
+	slices.Reverse(recent)
+	f.recent = recent
+	f.seeded = true
+	return nil
+}
+
+// removeTop removes the most recent block from the tracked chain
+func (f *ChainFollower) removeTop(ctx context.Context) error {
+	if len(f.recent) <= 1 {
+		// we cannot know where the fork happened
+		return ErrReorgTooDeep
+	}
+	top := f.recent[len(f.recent)-1]
+	if err := f.emit(ctx, &BlockEvent{Type: BlockRemoved, Header: top}); err != nil {
+		return err
+	}
+	f.recent = f.recent[:len(f.recent)-1]
+	f.next = top.Number
+	return nil
+}
+
+func (f *ChainFollower) emit(ctx context.Context, ev *BlockEvent) error {
+	select {
+	case f.events <- ev:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,136 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ModChain/ethrpc/abi"
+)
+
+// followTestNode is a handler serving the headers of a chain that can be replaced at any time to
+// simulate reorganizations
+type followTestNode struct {
+	lk     sync.Mutex
+	chain  []*BlockHeader
+	byHash map[abi.Hash]*BlockHeader
+}
+
+// fork replaces the chain from block from onwards with blocks up to to, tagged with id
+func (n *followTestNode) fork(id byte, from, to uint64) {
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	if n.byHash == nil {
+		n.byHash = make(map[abi.Hash]*BlockHeader)
+	}
+	n.chain = n.chain[:from]
+	for num := from; num <= to; num++ {
+		h := &BlockHeader{Number: num, Hash: abi.Hash{id, byte(num)}}
+		if num > 0 {
+			h.ParentHash = n.chain[num-1].Hash
+		}
+		n.chain = append(n.chain, h)
+		n.byHash[h.Hash] = h
+	}
+}
+
+func (n *followTestNode) SendCtx(ctx context.Context, req *Request) (json.RawMessage, error) {
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	params := req.Params.([]any)
+	var h *BlockHeader
+	switch req.Method {
+	case "eth_blockNumber":
+		return json.Marshal(hexUint(uint64(len(n.chain) - 1)))
+	case "eth_getBlockByNumber":
+		num, err := ReadUint64(json.Marshal(params[0]))
+		if err != nil {
+			return nil, err
+		}
+		if num < uint64(len(n.chain)) {
+			h = n.chain[num]
+		}
+	case "eth_getBlockByHash":
+		h = n.byHash[params[0].(abi.Hash)]
+	default:
+		return nil, &ErrorObject{Code: errMethodNotFound, Message: "method not found"}
+	}
+	if h == nil {
+		return json.RawMessage("null"), nil
+	}
+	return json.Marshal(map[string]any{
+		"number":     hexUint(h.Number),
+		"hash":       h.Hash,
+		"parentHash": h.ParentHash,
+		"timestamp":  "0x0",
+		"gasLimit":   "0x0",
+		"gasUsed":    "0x0",
+	})
+}
+
+func (n *followTestNode) DoCtx(ctx context.Context, method string, args ...any) (json.RawMessage, error) {
+	return n.SendCtx(ctx, NewRequest(method, args...))
+}
+
+func TestChainFollower(t *testing.T) {
+	node := &followTestNode{}
+	node.fork('a', 0, 5)
+
+	f := (&Api{node}).NewChainFollower(3)
+	f.Interval = time.Millisecond
+	f.Depth = 4
+	f.Start(context.Background())
+	f.Start(context.Background()) // no effect
+	defer f.Close()
+
+	tests := []struct {
+		name   string
+		fork   func()
+		expect []string
+	}{
+		{"backfill", func() {}, []string{"added a3", "added a4", "added a5"}},
+		{"new blocks", func() { node.fork('a', 6, 7) }, []string{"added a6", "added a7"}},
+		{"reorg", func() { node.fork('b', 6, 8) }, []string{"removed a7", "removed a6", "added b6", "added b7", "added b8"}},
+		{"shorter chain", func() { node.fork('c', 7, 7) }, []string{"removed b8", "removed b7", "added c7"}},
+		{"same height", func() { node.fork('d', 7, 7) }, []string{"removed c7", "added d7"}},
+	}
+	for _, test := range tests {
+		test.fork()
+		for n, exp := range test.expect {
+			var ev *BlockEvent
+			select {
+			case ev = <-f.Events:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("%s: timeout waiting for event %d, err = %v", test.name, n, f.Err())
+			}
+			if ev == nil {
+				t.Fatalf("%s: events closed, err = %v", test.name, f.Err())
+			}
+			if res := ev.Type.String() + " " + string(ev.Header.Hash[:1]) + strconv.FormatUint(ev.Header.Number, 10); res != exp {
+				t.Errorf("%s: event %d is %q, expected %q", test.name, n, res, exp)
+			}
+		}
+	}
+
+	// a reorg deeper than Depth stops the follower
+	node.fork('e', 1, 9)
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-f.Events:
+			if ok {
+				continue
+			}
+		case <-timeout:
+			t.Fatalf("follower did not stop")
+		}
+		break
+	}
+	if err := f.Err(); !errors.Is(err, ErrReorgTooDeep) {
+		t.Errorf("Err() = %v, expected %s", err, ErrReorgTooDeep)
+	}
+}
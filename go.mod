@@ -2,6 +2,13 @@ module github.com/ModChain/ethrpc
 
 go 1.22.2
 
-require github.com/KarpelesLab/typutil v0.2.26
+require (
+	github.com/KarpelesLab/typutil v0.2.26
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.3.0
+	golang.org/x/crypto v0.31.0
+)
 
-require github.com/KarpelesLab/pjson v0.1.7 // indirect
+require (
+	github.com/KarpelesLab/pjson v0.1.7 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+)
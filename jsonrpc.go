@@ -9,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"sync/atomic"
+	"time"
 )
 
 type Request struct {
@@ -33,7 +34,8 @@ func NewRequest(method string, params ...any) *Request {
 	return req
 }
 
-// HTTPRequest returns a [http.Request] for the given json-rpc request.
+// HTTPRequest returns a [http.Request] for the given json-rpc request. The span context of ctx,
+// if any, is propagated in the traceparent header.
 func (req *Request) HTTPRequest(ctx context.Context, host string) (*http.Request, error) {
 	reqEnc, err := json.Marshal(req)
 	if err != nil {
@@ -46,6 +48,7 @@ func (req *Request) HTTPRequest(ctx context.Context, host string) (*http.Request
 	}
 	hreq.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(reqEnc)), nil }
 	hreq.Header.Set("Content-Type", "application/json")
+	InjectTraceParent(ctx, hreq.Header)
 
 	return hreq, nil
 }
@@ -89,3 +92,15 @@ type ErrorObject struct {
 func (e *ErrorObject) Error() string {
 	return fmt.Sprintf("jsonrpc error %d: %s", e.Code, e.Message)
 }
+
+// HTTPError is returned when a server responds with an HTTP error status and no json-rpc response.
+// If the response holds a json-rpc error, the returned error wraps both.
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	RetryAfter time.Duration // delay requested by the Retry-After header of 429 and 503 responses
+}
+
+func (e *HTTPError) Error() string {
+	return "http error: " + e.Status
+}
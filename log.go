@@ -0,0 +1,89 @@
+package ethrpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/ModChain/ethrpc/abi"
+)
+
+// Log is an event log as returned by eth_getLogs or in transaction receipts
+type Log struct {
+	Address          abi.Address
+	Topics           []abi.Hash
+	Data             []byte
+	BlockNumber      uint64
+	BlockHash        abi.Hash
+	TransactionHash  abi.Hash
+	TransactionIndex uint64
+	LogIndex         uint64
+	Removed          bool // true if the log was removed because of a chain reorganization
+}
+
+type logJSON struct {
+	Address          abi.Address `json:"address"`
+	Topics           []abi.Hash  `json:"topics"`
+	Data             string      `json:"data"`
+	BlockNumber      string      `json:"blockNumber"`
+	BlockHash        *abi.Hash   `json:"blockHash"`
+	TransactionHash  *abi.Hash   `json:"transactionHash"`
+	TransactionIndex string      `json:"transactionIndex"`
+	LogIndex         string      `json:"logIndex"`
+	Removed          bool        `json:"removed"`
+}
+
+func (l *Log) UnmarshalJSON(buf []byte) error {
+	var v *logJSON
+	err := json.Unmarshal(buf, &v)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		return nil
+	}
+	*l = Log{Address: v.Address, Topics: v.Topics, Removed: v.Removed}
+	if v.BlockHash != nil {
+		l.BlockHash = *v.BlockHash
+	}
+	if v.TransactionHash != nil {
+		l.TransactionHash = *v.TransactionHash
+	}
+	if l.Data, err = hex.DecodeString(strings.TrimPrefix(v.Data, "0x")); err != nil {
+		return err
+	}
+	// pending logs have no block number or index
+	if l.BlockNumber, err = parseQuantity(v.BlockNumber); err != nil {
+		return err
+	}
+	if l.TransactionIndex, err = parseQuantity(v.TransactionIndex); err != nil {
+		return err
+	}
+	if l.LogIndex, err = parseQuantity(v.LogIndex); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (l *Log) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&logJSON{
+		Address:          l.Address,
+		Topics:           l.Topics,
+		Data:             "0x" + hex.EncodeToString(l.Data),
+		BlockNumber:      hexUint(l.BlockNumber),
+		BlockHash:        &l.BlockHash,
+		TransactionHash:  &l.TransactionHash,
+		TransactionIndex: hexUint(l.TransactionIndex),
+		LogIndex:         hexUint(l.LogIndex),
+		Removed:          l.Removed,
+	})
+}
+
+// parseQuantity decodes a hex quantity, an empty string being decoded as zero
+func parseQuantity(s string) (uint64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(s, 0, 64)
+}
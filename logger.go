@@ -0,0 +1,110 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogOptions configures how [RPC] logs requests when its Logger is set
+type LogOptions struct {
+	Level      slog.Leveler // level of successful requests, defaults to slog.LevelDebug
+	ErrorLevel slog.Leveler // level of failed requests, defaults to slog.LevelWarn
+	Params     bool         // log request params
+	Results    bool         // log results
+	MaxPayload int          // params and results are truncated to this size, defaults to 512 bytes
+}
+
+// methods whose params are never logged
+var logRedactedMethods = []string{
+	"eth_sendRawTransaction",
+	"eth_sendRawTransactionConditional",
+	"eth_sendPrivateTransaction",
+	"eth_sendBundle",
+	"personal_*",
+}
+
+// log logs a request performed by r
+func (r *RPC) log(ctx context.Context, req *Request, start time.Time, res json.RawMessage, err error) {
+	if r.Logger == nil {
+		return
+	}
+	opts := &r.LogOptions
+	var level slog.Level
+	switch {
+	case err != nil && opts.ErrorLevel != nil:
+		level = opts.ErrorLevel.Level()
+	case err != nil:
+		level = slog.LevelWarn
+	case opts.Level != nil:
+		level = opts.Level.Level()
+	default:
+		level = slog.LevelDebug
+	}
+	if !r.Logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("endpoint", redactURL(r.host)),
+		slog.Any("id", req.Id),
+		slog.Duration("duration", time.Since(start)),
+	}
+	if opts.Params {
+		if matchMethod(logRedactedMethods, req.Method) {
+			attrs = append(attrs, slog.String("params", "[redacted]"))
+		} else {
+			params, _ := json.Marshal(req.Params)
+			attrs = append(attrs, slog.String("params", truncatePayload(params, opts.MaxPayload)))
+		}
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", redactError(err, r.host)))
+	} else if opts.Results {
+		attrs = append(attrs, slog.String("result", truncatePayload(res, opts.MaxPayload)))
+	}
+	r.Logger.LogAttrs(ctx, level, "rpc request", attrs...)
+}
+
+// redactURL returns only the scheme and host of the given url, as api keys are often part of
+// the path or query
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return "[invalid url]"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// redactError returns the text of err with the url of a [url.Error] and the given urls redacted
+func redactError(err error, urls ...string) string {
+	s := err.Error()
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		// first as it usually contains the others
+		urls = append([]string{uerr.URL}, urls...)
+	}
+	for _, u := range urls {
+		if u != "" {
+			s = strings.ReplaceAll(s, u, redactURL(u))
+		}
+	}
+	return s
+}
+
+// truncatePayload returns buf as a string of at most max bytes
+func truncatePayload(buf []byte, max int) string {
+	if max <= 0 {
+		max = 512
+	}
+	if len(buf) <= max {
+		return string(buf)
+	}
+	return strings.ToValidUTF8(string(buf[:max]), "") + "...(" + strconv.Itoa(len(buf)) + " bytes)"
+}
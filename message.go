@@ -0,0 +1,121 @@
+package ethrpc
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+
+	"github.com/ModChain/ethrpc/abi"
+)
+
+var (
+	erc1271ABI = abi.MustParseHuman(
+		"function isValidSignature(bytes32 hash, bytes signature) view returns (bytes4)",
+	)
+	erc1271Magic = []byte{0x16, 0x26, 0xba, 0x7e}
+
+	// ERC-6492 wrapped signatures end with this suffix
+	erc6492Suffix = bytes.Repeat([]byte{0x64, 0x92}, 16)
+	erc6492Args   = abi.Arguments{
+		{Name: "factory", Type: abi.MustParseType("address")},
+		{Name: "factoryCalldata", Type: abi.MustParseType("bytes")},
+		{Name: "signature", Type: abi.MustParseType("bytes")},
+	}
+)
+
+// HashMessage returns the EIP-191 hash of msg, as signed by personal_sign
+func HashMessage(msg []byte) abi.Hash {
+	prefix := "\x19Ethereum Signed Message:\n" + strconv.Itoa(len(msg))
+	return abi.Keccak256Hash([]byte(prefix), msg)
+}
+
+// SignMessage signs msg following EIP-191 and returns a 65 bytes signature with V being 27 or 28,
+// as returned by personal_sign
+func SignMessage(s Signer, msg []byte) ([]byte, error) {
+	hash := HashMessage(msg)
+	sig, err := s.SignHash(hash[:])
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+// RecoverMessage returns the address of the account that signed msg following EIP-191
+func RecoverMessage(msg, sig []byte) (abi.Address, error) {
+	hash := HashMessage(msg)
+	return RecoverAddress(hash[:], sig)
+}
+
+// VerifyMessage checks that sig is a valid EIP-191 signature of msg by addr, see
+// [Api.VerifySignature]
+func (a *Api) VerifyMessage(ctx context.Context, addr abi.Address, msg, sig []byte) (bool, error) {
+	return a.VerifySignature(ctx, addr, HashMessage(msg), sig)
+}
+
+// VerifySignature checks that sig is a valid signature of hash by addr. Signatures of smart
+// contract wallets are checked with ERC-1271, and ERC-6492 signatures of wallets that are not
+// deployed yet are checked by simulating the deployment through Multicall3. An error is only
+// returned if the verification could not be performed, such as ErrMulticallNotSupported when an
+// ERC-6492 signature is checked on a chain without Multicall3.
+func (a *Api) VerifySignature(ctx context.Context, addr abi.Address, hash abi.Hash, sig []byte) (bool, error) {
+	var factory *abi.Address
+	var factoryCalldata []byte
+	if bytes.HasSuffix(sig, erc6492Suffix) {
+		var wrapped struct {
+			Factory         abi.Address
+			FactoryCalldata []byte
+			Signature       []byte
+		}
+		if err := erc6492Args.UnpackInto(&wrapped, sig[:len(sig)-len(erc6492Suffix)]); err != nil {
+			return false, nil
+		}
+		factory, factoryCalldata, sig = &wrapped.Factory, wrapped.FactoryCalldata, wrapped.Signature
+	}
+
+	code, err := a.GetCode(ctx, addr, "latest")
+	if err != nil {
+		return false, err
+	}
+	data, err := erc1271ABI.Method("isValidSignature").Pack(hash, sig)
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case len(code) > 0:
+		res, err := a.Call(ctx, &CallMsg{To: &addr, Data: data}, "latest")
+		if err != nil {
+			if isRevert(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		return bytes.HasPrefix(res, erc1271Magic), nil
+	case factory != nil:
+		// deploy the wallet and check the signature within the same simulated call
+		m := a.NewMulticall()
+		mcode, err := a.GetCode(ctx, m.Address, m.Block)
+		if err != nil {
+			return false, err
+		}
+		if len(mcode) == 0 {
+			// calls would be performed individually and the wallet would not exist when checking
+			return false, ErrMulticallNotSupported
+		}
+		deployed := true
+		m.deployed = &deployed
+		m.Add(*factory, factoryCalldata)
+		check := m.Add(addr, data)
+		if err := m.Run(ctx); err != nil {
+			return false, err
+		}
+		return check.Success && bytes.HasPrefix(check.ReturnData, erc1271Magic), nil
+	}
+
+	signer, err := RecoverAddress(hash[:], sig)
+	if err != nil {
+		return false, nil
+	}
+	return signer == addr, nil
+}
@@ -0,0 +1,349 @@
+package ethrpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMetricsBuckets are the default latency histogram buckets, in seconds
+var DefaultMetricsBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metrics collects per-endpoint and per-method request metrics, and exposes them in the
+// Prometheus text exposition format. Requests are observed through [Metrics.Middleware]. Metrics
+// is not a prometheus.Collector, use [Metrics.Handler] to append its output to an existing
+// /metrics handler.
+type Metrics struct {
+	Namespace string    // prefix of metric names, defaults to "ethrpc"
+	Buckets   []float64 // latency histogram buckets in seconds, defaults to DefaultMetricsBuckets. Changes only apply to new histograms.
+
+	lk        sync.Mutex
+	requests  map[[2]string]uint64 // endpoint, method
+	errors    map[[3]string]uint64 // endpoint, method, code
+	status    map[[2]string]uint64 // endpoint, http status
+	latency   map[[2]string]*metricsHistogram
+	inflight  map[string]int64
+	endpoints map[string]*endpointMetrics
+}
+
+type metricsHistogram struct {
+	buckets []float64 // buckets at the time the histogram was created
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+type endpointMetrics struct {
+	up   bool
+	head uint64
+	lag  time.Duration
+}
+
+// NewMetrics returns a new [Metrics]
+func NewMetrics() *Metrics {
+	return &Metrics{
+		requests:  make(map[[2]string]uint64),
+		errors:    make(map[[3]string]uint64),
+		status:    make(map[[2]string]uint64),
+		latency:   make(map[[2]string]*metricsHistogram),
+		inflight:  make(map[string]int64),
+		endpoints: make(map[string]*endpointMetrics),
+	}
+}
+
+// EndpointLabel returns the label used for a RPC endpoint, which is its host name so that
+// credentials in the URL are not exposed
+func EndpointLabel(host string) string {
+	u, err := url.Parse(host)
+	if err != nil || u.Host == "" {
+		return "local"
+	}
+	return u.Host
+}
+
+// Middleware returns a [Middleware] recording the requests it processes under the given
+// endpoint label
+func (m *Metrics) Middleware(endpoint string) Middleware {
+	return func(next RequestHandler) RequestHandler {
+		return RequestHandlerFunc(func(ctx context.Context, req *Request) (json.RawMessage, error) {
+			m.begin(endpoint)
+			start := time.Now()
+			res, err := next.SendCtx(ctx, req)
+			m.end(endpoint, req.Method, time.Since(start), err)
+			return res, err
+		})
+	}
+}
+
+// Instrument adds the metrics middleware to the given servers
+func (m *Metrics) Instrument(servers ...*RPC) {
+	for _, r := range servers {
+		r.Use(m.Middleware(EndpointLabel(r.GetHost())))
+	}
+}
+
+// Evaluate calls [Evaluate], records the health, head block and latency of each server and
+// instruments the servers that are returned
+func (m *Metrics) Evaluate(ctx context.Context, servers ...string) (Handler, error) {
+	h, err := Evaluate(ctx, servers...)
+	var list RPCList
+	switch v := h.(type) {
+	case *RPC:
+		list = RPCList{v}
+	case RPCList:
+		list = v
+	}
+
+	m.lk.Lock()
+	for _, s := range servers {
+		m.endpoint(EndpointLabel(s)).up = false
+	}
+	for _, r := range list {
+		e := m.endpoint(EndpointLabel(r.GetHost()))
+		e.up = true
+		if r.block != 0 {
+			e.head, e.lag = r.block, r.lag
+		}
+	}
+	m.lk.Unlock()
+
+	m.Instrument(list...)
+	return h, err
+}
+
+// SetEndpoint records the health and head block of an endpoint
+func (m *Metrics) SetEndpoint(endpoint string, up bool, head uint64, lag time.Duration) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	e := m.endpoint(endpoint)
+	e.up, e.head, e.lag = up, head, lag
+}
+
+func (m *Metrics) endpoint(endpoint string) *endpointMetrics {
+	e, ok := m.endpoints[endpoint]
+	if !ok {
+		e = &endpointMetrics{}
+		m.endpoints[endpoint] = e
+	}
+	return e
+}
+
+func (m *Metrics) begin(endpoint string) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	m.inflight[endpoint] += 1
+}
+
+func (m *Metrics) end(endpoint, method string, d time.Duration, err error) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	m.inflight[endpoint] -= 1
+	key := [2]string{endpoint, method}
+	m.requests[key] += 1
+
+	h, ok := m.latency[key]
+	if !ok {
+		buckets := slices.Clone(m.buckets())
+		h = &metricsHistogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+		m.latency[key] = h
+	}
+	secs := d.Seconds()
+	for n, b := range h.buckets {
+		if secs <= b {
+			h.counts[n] += 1
+		}
+	}
+	h.sum += secs
+	h.count += 1
+
+	var obj *ErrorObject
+	var herr *HTTPError
+	status := "200"
+	if errors.As(err, &herr) {
+		status = strconv.Itoa(herr.StatusCode)
+	}
+	switch {
+	case err == nil:
+		m.status[[2]string{endpoint, status}] += 1
+	case errors.As(err, &obj):
+		m.status[[2]string{endpoint, status}] += 1
+		m.errors[[3]string{endpoint, method, strconv.Itoa(obj.Code)}] += 1
+	case herr != nil:
+		m.status[[2]string{endpoint, status}] += 1
+		m.errors[[3]string{endpoint, method, "http_" + status}] += 1
+	default:
+		m.errors[[3]string{endpoint, method, "transport"}] += 1
+	}
+}
+
+func (m *Metrics) buckets() []float64 {
+	if m.Buckets == nil {
+		return DefaultMetricsBuckets
+	}
+	return m.Buckets
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format
+func (m *Metrics) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(rw)
+}
+
+// Handler returns a [http.Handler] serving the output of next, typically the handler of an
+// existing Prometheus registry, followed by these metrics. next is asked for the uncompressed text
+// format so that both can be concatenated. If next is nil, only these metrics are served.
+func (m *Metrics) Handler(next http.Handler) http.Handler {
+	if next == nil {
+		return m
+	}
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		r := req.Clone(req.Context())
+		r.Header.Set("Accept", "text/plain; version=0.0.4")
+		r.Header.Del("Accept-Encoding")
+		buf := &metricsResponse{header: make(http.Header)}
+		next.ServeHTTP(buf, r)
+
+		if buf.status != 0 && buf.status != http.StatusOK {
+			for k, v := range buf.header {
+				rw.Header()[k] = v
+			}
+			rw.WriteHeader(buf.status)
+			rw.Write(buf.body.Bytes())
+			return
+		}
+		rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if b := buf.body.Bytes(); len(b) > 0 {
+			rw.Write(b)
+			if b[len(b)-1] != '\n' {
+				io.WriteString(rw, "\n")
+			}
+		}
+		m.WriteTo(rw)
+	})
+}
+
+// metricsResponse buffers the response of the handler wrapped by [Metrics.Handler]
+type metricsResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *metricsResponse) Header() http.Header { return r.header }
+
+func (r *metricsResponse) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *metricsResponse) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(p)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format to w, allowing them to be
+// appended to the output of an existing metrics handler
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	ns := m.Namespace
+	if ns == "" {
+		ns = "ethrpc"
+	}
+	cw := &countingWriter{w: w}
+	b := bufio.NewWriter(cw)
+
+	m.lk.Lock()
+	header := func(name, typ, help string) {
+		fmt.Fprintf(b, "# HELP %s_%s %s\n# TYPE %s_%s %s\n", ns, name, help, ns, name, typ)
+	}
+
+	header("requests_total", "counter", "Total number of json-rpc requests.")
+	for _, k := range sortedKeys(m.requests) {
+		fmt.Fprintf(b, "%s_requests_total{endpoint=%s,method=%s} %d\n", ns, quoteLabel(k[0]), quoteLabel(k[1]), m.requests[k])
+	}
+	header("request_errors_total", "counter", "Total number of failed json-rpc requests by error code.")
+	for _, k := range sortedKeys(m.errors) {
+		fmt.Fprintf(b, "%s_request_errors_total{endpoint=%s,method=%s,code=%s} %d\n", ns, quoteLabel(k[0]), quoteLabel(k[1]), quoteLabel(k[2]), m.errors[k])
+	}
+	header("http_responses_total", "counter", "Total number of http responses by status code.")
+	for _, k := range sortedKeys(m.status) {
+		fmt.Fprintf(b, "%s_http_responses_total{endpoint=%s,status=%s} %d\n", ns, quoteLabel(k[0]), quoteLabel(k[1]), m.status[k])
+	}
+	header("request_duration_seconds", "histogram", "Duration of json-rpc requests.")
+	for _, k := range sortedKeys(m.latency) {
+		h := m.latency[k]
+		labels := fmt.Sprintf("endpoint=%s,method=%s", quoteLabel(k[0]), quoteLabel(k[1]))
+		for n, bucket := range h.buckets {
+			fmt.Fprintf(b, "%s_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", ns, labels, strconv.FormatFloat(bucket, 'g', -1, 64), h.counts[n])
+		}
+		fmt.Fprintf(b, "%s_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", ns, labels, h.count)
+		fmt.Fprintf(b, "%s_request_duration_seconds_sum{%s} %s\n", ns, labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(b, "%s_request_duration_seconds_count{%s} %d\n", ns, labels, h.count)
+	}
+	header("requests_in_flight", "gauge", "Number of json-rpc requests being performed.")
+	for _, k := range sortedKeys(m.inflight) {
+		fmt.Fprintf(b, "%s_requests_in_flight{endpoint=%s} %d\n", ns, quoteLabel(k), m.inflight[k])
+	}
+	endpoints := sortedKeys(m.endpoints)
+	header("endpoint_up", "gauge", "Whether the endpoint responded to the last evaluation.")
+	for _, k := range endpoints {
+		up := 0
+		if m.endpoints[k].up {
+			up = 1
+		}
+		fmt.Fprintf(b, "%s_endpoint_up{endpoint=%s} %d\n", ns, quoteLabel(k), up)
+	}
+	header("endpoint_head_block", "gauge", "Latest block number reported by the endpoint.")
+	for _, k := range endpoints {
+		fmt.Fprintf(b, "%s_endpoint_head_block{endpoint=%s} %d\n", ns, quoteLabel(k), m.endpoints[k].head)
+	}
+	header("endpoint_latency_seconds", "gauge", "Response time of the endpoint during the last evaluation.")
+	for _, k := range endpoints {
+		fmt.Fprintf(b, "%s_endpoint_latency_seconds{endpoint=%s} %s\n", ns, quoteLabel(k), strconv.FormatFloat(m.endpoints[k].lag.Seconds(), 'g', -1, 64))
+	}
+	m.lk.Unlock()
+
+	err := b.Flush()
+	return cw.n, err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// quoteLabel returns a quoted and escaped label value
+func quoteLabel(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+func sortedKeys[K [2]string | [3]string | string, V any](m map[K]V) []K {
+	res := make([]K, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return fmt.Sprint(res[i]) < fmt.Sprint(res[j])
+	})
+	return res
+}
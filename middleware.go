@@ -0,0 +1,144 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// RequestHandler processes a json-rpc [Request]. [RPC] and [RPCList] implement it.
+type RequestHandler interface {
+	SendCtx(ctx context.Context, req *Request) (json.RawMessage, error)
+}
+
+// RequestHandlerFunc is a function implementing [RequestHandler]. It also implements [Handler].
+type RequestHandlerFunc func(ctx context.Context, req *Request) (json.RawMessage, error)
+
+// SendCtx calls f
+func (f RequestHandlerFunc) SendCtx(ctx context.Context, req *Request) (json.RawMessage, error) {
+	return f(ctx, req)
+}
+
+// DoCtx calls f with a new request
+func (f RequestHandlerFunc) DoCtx(ctx context.Context, method string, args ...any) (json.RawMessage, error) {
+	return f(ctx, NewRequest(method, args...))
+}
+
+// Middleware wraps a [RequestHandler], allowing to inspect or modify requests and their results.
+// It can be applied with [Chain], [RPC.Use], [RPCList.Use] or [Proxy.Use].
+type Middleware func(next RequestHandler) RequestHandler
+
+// Chain returns a [Handler] passing requests through the given middlewares before sending them
+// to h. The first middleware is the outermost one.
+func Chain(h Handler, mw ...Middleware) RequestHandlerFunc {
+	return chainMiddleware(AsRequestHandler(h), mw).SendCtx
+}
+
+func chainMiddleware(h RequestHandler, mw []Middleware) RequestHandler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+// AsRequestHandler returns h as a [RequestHandler]. If h does not implement it, requests with
+// named params fail.
+func AsRequestHandler(h Handler) RequestHandler {
+	if rh, ok := h.(RequestHandler); ok {
+		return rh
+	}
+	return RequestHandlerFunc(func(ctx context.Context, req *Request) (json.RawMessage, error) {
+		switch params := req.Params.(type) {
+		case nil:
+			return h.DoCtx(ctx, req.Method)
+		case []any:
+			return h.DoCtx(ctx, req.Method, params...)
+		}
+		return nil, &ErrorObject{Code: errInvalidParams, Message: "method only supports positional params"}
+	})
+}
+
+// RetryMiddleware retries requests failing because of transport errors up to attempts times,
+// waiting backoff after the first failure and doubling it after each subsequent one. Errors
+// returned by the server are not retried.
+func RetryMiddleware(attempts int, backoff time.Duration) Middleware {
+	return func(next RequestHandler) RequestHandler {
+		return RequestHandlerFunc(func(ctx context.Context, req *Request) (json.RawMessage, error) {
+			delay := backoff
+			for n := 1; ; n++ {
+				res, err := next.SendCtx(ctx, req)
+				var obj *ErrorObject
+				if err == nil || n >= attempts || errors.As(err, &obj) || ctx.Err() != nil {
+					return res, err
+				}
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+					return nil, err
+				}
+				delay *= 2
+			}
+		})
+	}
+}
+
+// TimeoutMiddleware limits the duration of each request
+func TimeoutMiddleware(d time.Duration) Middleware {
+	return func(next RequestHandler) RequestHandler {
+		return RequestHandlerFunc(func(ctx context.Context, req *Request) (json.RawMessage, error) {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			return next.SendCtx(ctx, req)
+		})
+	}
+}
+
+// RewriteMiddleware renames methods, for example to send eth_sendRawTransaction to a private
+// mempool method
+func RewriteMiddleware(methods map[string]string) Middleware {
+	return func(next RequestHandler) RequestHandler {
+		return RequestHandlerFunc(func(ctx context.Context, req *Request) (json.RawMessage, error) {
+			if m, ok := methods[req.Method]; ok {
+				r := *req
+				r.Method = m
+				req = &r
+			}
+			return next.SendCtx(ctx, req)
+		})
+	}
+}
+
+// MethodFilterMiddleware refuses methods not in allow (if not empty) or in deny. A trailing *
+// matches any suffix, as in "debug_*".
+func MethodFilterMiddleware(allow, deny []string) Middleware {
+	return func(next RequestHandler) RequestHandler {
+		return RequestHandlerFunc(func(ctx context.Context, req *Request) (json.RawMessage, error) {
+			if (len(allow) > 0 && !matchMethod(allow, req.Method)) || matchMethod(deny, req.Method) {
+				return nil, &ErrorObject{Code: errMethodNotFound, Message: "method " + req.Method + " is not available"}
+			}
+			return next.SendCtx(ctx, req)
+		})
+	}
+}
+
+// CacheMiddleware caches results, see [Cache]. The settings of c are used to build a new cache
+// for each handler the middleware is applied to, c itself is neither modified nor used to store
+// results and may be nil.
+func CacheMiddleware(c *Cache) Middleware {
+	return func(next RequestHandler) RequestHandler {
+		cache := NewCache(RequestHandlerFunc(next.SendCtx))
+		if c != nil {
+			cache.MaxSize = c.MaxSize
+			cache.LatestTTL = c.LatestTTL
+			cache.FinalizedTTL = c.FinalizedTTL
+			cache.FinalityDepth = c.FinalityDepth
+		}
+		return RequestHandlerFunc(func(ctx context.Context, req *Request) (json.RawMessage, error) {
+			if params, ok := req.Params.([]any); ok {
+				return cache.DoCtx(ctx, req.Method, params...)
+			}
+			return next.SendCtx(ctx, req)
+		})
+	}
+}
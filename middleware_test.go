@@ -0,0 +1,142 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// middlewareTestRecord returns a middleware appending name to the trace before and after
+// calling the next handler
+func middlewareTestRecord(name string, trace *[]string) Middleware {
+	return func(next RequestHandler) RequestHandler {
+		return RequestHandlerFunc(func(ctx context.Context, req *Request) (json.RawMessage, error) {
+			*trace = append(*trace, name+">")
+			res, err := next.SendCtx(ctx, req)
+			*trace = append(*trace, "<"+name)
+			return res, err
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	var trace []string
+	h := Chain(RequestHandlerFunc(func(ctx context.Context, req *Request) (json.RawMessage, error) {
+		trace = append(trace, req.Method)
+		return json.RawMessage(`"0x1"`), nil
+	}), middlewareTestRecord("a", &trace), middlewareTestRecord("b", &trace))
+
+	res, err := h.DoCtx(context.Background(), "eth_chainId")
+	if err != nil || string(res) != `"0x1"` {
+		t.Fatalf("got %s, %v", res, err)
+	}
+	if s := strings.Join(trace, " "); s != "a> b> eth_chainId <b <a" {
+		t.Errorf("unexpected order %s", s)
+	}
+}
+
+func TestMiddlewares(t *testing.T) {
+	transport := errors.New("connection reset")
+	revert := &ErrorObject{Code: 3, Message: "execution reverted"}
+
+	tests := []struct {
+		name   string
+		mw     Middleware
+		method string
+		fails  []error // errors returned by the successive upstream calls
+		calls  int
+		err    error
+		sent   string // method received upstream
+	}{
+		{"retry success", RetryMiddleware(3, time.Millisecond), "eth_call", []error{transport, transport}, 3, nil, "eth_call"},
+		{"retry exhausted", RetryMiddleware(2, time.Millisecond), "eth_call", []error{transport, transport, transport}, 2, transport, "eth_call"},
+		{"retry server error", RetryMiddleware(3, time.Millisecond), "eth_call", []error{revert}, 1, revert, "eth_call"},
+		{"rewrite", RewriteMiddleware(map[string]string{"eth_sendRawTransaction": "eth_sendPrivateRawTransaction"}), "eth_sendRawTransaction", nil, 1, nil, "eth_sendPrivateRawTransaction"},
+		{"rewrite other", RewriteMiddleware(map[string]string{"eth_sendRawTransaction": "x"}), "eth_call", nil, 1, nil, "eth_call"},
+		{"filter allowed", MethodFilterMiddleware([]string{"eth_*"}, []string{"eth_sign"}), "eth_call", nil, 1, nil, "eth_call"},
+		{"filter denied", MethodFilterMiddleware([]string{"eth_*"}, []string{"eth_sign"}), "eth_sign", nil, 0, &ErrorObject{Code: errMethodNotFound, Message: "method eth_sign is not available"}, ""},
+		{"filter not allowed", MethodFilterMiddleware([]string{"eth_*"}, nil), "debug_traceCall", nil, 0, &ErrorObject{Code: errMethodNotFound, Message: "method debug_traceCall is not available"}, ""},
+	}
+	for _, test := range tests {
+		calls := 0
+		var sent string
+		h := Chain(RequestHandlerFunc(func(ctx context.Context, req *Request) (json.RawMessage, error) {
+			calls++
+			sent = req.Method
+			if calls <= len(test.fails) {
+				return nil, test.fails[calls-1]
+			}
+			return json.RawMessage(`"0x1"`), nil
+		}), test.mw)
+
+		_, err := h.DoCtx(context.Background(), test.method)
+		var obj *ErrorObject
+		switch {
+		case errors.As(test.err, &obj):
+			if res, ok := err.(*ErrorObject); !ok || *res != *obj {
+				t.Errorf("%s: got error %v, expected %v", test.name, err, test.err)
+			}
+		case !errors.Is(err, test.err):
+			t.Errorf("%s: got error %v, expected %v", test.name, err, test.err)
+		}
+		if calls != test.calls || sent != test.sent {
+			t.Errorf("%s: upstream called %d times with %q, expected %d with %q", test.name, calls, sent, test.calls, test.sent)
+		}
+	}
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	h := Chain(RequestHandlerFunc(func(ctx context.Context, req *Request) (json.RawMessage, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}), TimeoutMiddleware(5*time.Millisecond))
+	if _, err := h.DoCtx(context.Background(), "eth_call"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v, expected context.DeadlineExceeded", err)
+	}
+}
+
+func TestRPCForwardMiddleware(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var req Request
+		json.NewDecoder(r.Body).Decode(&req)
+		rw.Header().Set("X-Upstream", "1")
+		json.NewEncoder(rw).Encode(map[string]any{"jsonrpc": "2.0", "id": req.Id, "result": req.Method})
+	}))
+	defer upstream.Close()
+
+	tests := []struct {
+		name   string
+		mw     []Middleware
+		method string
+		status int
+		expect string
+	}{
+		{"no middleware", nil, "eth_chainId", 200, `{"id":1,"jsonrpc":"2.0","result":"eth_chainId"}`},
+		{"rewrite", []Middleware{RewriteMiddleware(map[string]string{"eth_chainId": "net_version"})}, "eth_chainId", 200, `{"jsonrpc":"2.0","result":"net_version","id":1}`},
+		{"denied", []Middleware{MethodFilterMiddleware(nil, []string{"eth_chainId"})}, "eth_chainId", 200, `{"jsonrpc":"2.0","result":null,"error":{"code":-32601,"message":"method eth_chainId is not available"},"id":1}`},
+	}
+	for _, test := range tests {
+		r := New(upstream.URL)
+		if test.mw != nil {
+			r.Use(test.mw...)
+		}
+		req := NewRequest(test.method)
+		req.Id = 1
+		rw := httptest.NewRecorder()
+		r.Forward(context.Background(), rw, req, nil)
+		if rw.Code != test.status {
+			t.Errorf("%s: got status %d, expected %d", test.name, rw.Code, test.status)
+		}
+		if res := strings.TrimSpace(rw.Body.String()); res != test.expect {
+			t.Errorf("%s: got %s, expected %s", test.name, res, test.expect)
+		}
+		if upstreamHeader := rw.Header().Get("X-Upstream") != ""; upstreamHeader != (test.mw == nil) {
+			t.Errorf("%s: upstream headers copied = %v", test.name, upstreamHeader)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package ethrpc
+
+import (
+	"context"
+
+	"github.com/ModChain/ethrpc/abi"
+)
+
+// Multicall3Address is the address Multicall3 is deployed at on most chains
+var Multicall3Address = abi.MustParseAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+var multicall3 = abi.MustParseHuman(
+	"function aggregate3((address target, bool allowFailure, bytes callData)[] calls) payable returns ((bool success, bytes returnData)[] returnData)",
+)
+
+// MulticallCall is a single call within a [Multicall]
+type MulticallCall struct {
+	To           abi.Address
+	Data         []byte
+	AllowFailure bool   // if false, a failure of this call fails all the calls of its batch
+	Gas          uint64 // optional estimate of the gas used by the call, used to split batches
+
+	// results, set by Run
+	Success    bool
+	ReturnData []byte
+	Err        error
+
+	abi    *abi.ABI
+	method *abi.Method
+	target any
+}
+
+// Multicall packs many eth_call into calls to Multicall3's aggregate3. On chains where Multicall3
+// is not deployed, calls are performed individually.
+type Multicall struct {
+	Address     abi.Address // address of Multicall3, defaults to Multicall3Address
+	MaxCalldata int         // maximum calldata size of a single aggregate3 call, defaults to 100kB
+	MaxGas      uint64      // maximum sum of the Gas of calls in a single aggregate3 call, if set
+	Block       string      // block calls are performed at, defaults to "latest"
+	Calls       []*MulticallCall
+
+	api      *Api
+	deployed *bool
+}
+
+// NewMulticall returns a new empty [Multicall]
+func (a *Api) NewMulticall() *Multicall {
+	return &Multicall{Address: Multicall3Address, MaxCalldata: 100_000, Block: "latest", api: a}
+}
+
+// Add adds a raw call to the multicall. Failures are allowed by default.
+func (m *Multicall) Add(to abi.Address, data []byte) *MulticallCall {
+	call := &MulticallCall{To: to, Data: data, AllowFailure: true}
+	m.Calls = append(m.Calls, call)
+	return call
+}
+
+// AddCall adds a call to a contract method. Once Run is complete, the returned values are decoded
+// into target if it is not nil, and reverts are decoded using the contract's abi.
+func (m *Multicall) AddCall(c *Contract, target any, method string, args ...any) (*MulticallCall, error) {
+	meth, err := c.Method(method, len(args))
+	if err != nil {
+		return nil, err
+	}
+	data, err := meth.Pack(args...)
+	if err != nil {
+		return nil, err
+	}
+	call := m.Add(c.Address, data)
+	call.abi = c.ABI
+	call.method = meth
+	call.target = target
+	return call, nil
+}
+
+// Run performs all the calls. An error is returned only if calls could not be performed at all,
+// results of each call are found in its Success, ReturnData and Err fields.
+func (m *Multicall) Run(ctx context.Context) error {
+	if len(m.Calls) == 0 {
+		return nil
+	}
+	if m.deployed == nil {
+		code, err := m.api.GetCode(ctx, m.Address, m.Block)
+		if err != nil {
+			return err
+		}
+		deployed := len(code) > 0
+		m.deployed = &deployed
+	}
+	if !*m.deployed {
+		return m.runIndividually(ctx)
+	}
+
+	for _, batch := range m.batches() {
+		if err := m.runBatch(ctx, batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// batches splits the calls according to MaxCalldata and MaxGas
+func (m *Multicall) batches() [][]*MulticallCall {
+	var (
+		res   [][]*MulticallCall
+		cur   []*MulticallCall
+		size  int
+		gas   uint64
+		limit = m.MaxCalldata
+	)
+	if limit <= 0 {
+		limit = 100_000
+	}
+	for _, call := range m.Calls {
+		// each call uses 5 words for its tuple, offset and length, plus the padded data
+		callSize := 160 + (len(call.Data)+31)/32*32
+		if len(cur) > 0 && (size+callSize > limit || m.MaxGas > 0 && gas+call.Gas > m.MaxGas) {
+			res = append(res, cur)
+			cur, size, gas = nil, 0, 0
+		}
+		cur = append(cur, call)
+		size += callSize
+		gas += call.Gas
+	}
+	return append(res, cur)
+}
+
+func (m *Multicall) runBatch(ctx context.Context, batch []*MulticallCall) error {
+	calls := make([]any, len(batch))
+	for n, call := range batch {
+		calls[n] = []any{call.To, call.AllowFailure, call.Data}
+	}
+	data, err := multicall3.Methods[0].Pack(calls)
+	if err != nil {
+		return err
+	}
+	res, err := m.api.Call(ctx, &CallMsg{To: &m.Address, Data: data}, m.Block)
+	if err != nil {
+		if _, ok := revertData(err); !ok {
+			return err
+		}
+		// a call that does not allow failure failed
+		err = decodeRevert(err, nil)
+		for _, call := range batch {
+			call.Success = false
+			call.Err = err
+		}
+		return nil
+	}
+
+	var results []struct {
+		Success    bool
+		ReturnData []byte
+	}
+	if err := multicall3.Methods[0].UnpackInto(&results, res); err != nil {
+		return err
+	}
+	if len(results) != len(batch) {
+		return ErrInvalidResponse
+	}
+	for n, call := range batch {
+		call.setResult(results[n].Success, results[n].ReturnData, nil)
+	}
+	return nil
+}
+
+// runIndividually performs each call using a separate eth_call
+func (m *Multicall) runIndividually(ctx context.Context) error {
+	for _, call := range m.Calls {
+		res, err := m.api.Call(ctx, &CallMsg{To: &call.To, Data: call.Data}, m.Block)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			call.setResult(false, nil, decodeRevert(err, call.abi))
+			continue
+		}
+		call.setResult(true, res, nil)
+	}
+	return nil
+}
+
+func (call *MulticallCall) setResult(success bool, data []byte, err error) {
+	call.Success = success
+	call.ReturnData = data
+	call.Err = err
+	if !success {
+		if err == nil {
+			call.Err = newRevertError(data, call.abi, nil)
+		}
+		return
+	}
+	if call.method != nil && call.target != nil {
+		call.Err = call.method.UnpackInto(call.target, data)
+	}
+}
@@ -0,0 +1,165 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ModChain/ethrpc/abi"
+)
+
+var (
+	multicallTestABI = abi.MustParseHuman(
+		"function value(uint256 x) view returns (uint256)",
+		"error Low(uint256 x)",
+	)
+	multicallTestGood = abi.MustParseAddress("0x000000000000000000000000000000000000600d")
+	multicallTestBad  = abi.MustParseAddress("0x0000000000000000000000000000000000000bad")
+)
+
+// multicallTestNode executes calls to multicallTestGood, which returns its argument, and to
+// multicallTestBad, which reverts with Low(argument), directly or through aggregate3
+type multicallTestNode struct {
+	deployed   bool
+	aggregates int
+	calls      int
+}
+
+func (n *multicallTestNode) exec(to abi.Address, data []byte) (ret []byte, ok bool) {
+	if to == multicallTestBad {
+		return append(multicallTestABI.Errors[0].Selector(), data[4:]...), false
+	}
+	return data[4:], true
+}
+
+func (n *multicallTestNode) DoCtx(ctx context.Context, method string, args ...any) (json.RawMessage, error) {
+	switch method {
+	case "eth_getCode":
+		if n.deployed {
+			return json.RawMessage(`"0x6080"`), nil
+		}
+		return json.RawMessage(`"0x"`), nil
+	case "eth_call":
+	default:
+		return nil, ErrMethodNotFound
+	}
+	msg := args[0].(*CallMsg)
+	if *msg.To != Multicall3Address {
+		n.calls++
+		ret, ok := n.exec(*msg.To, msg.Data)
+		if !ok {
+			return nil, &ErrorObject{Code: 3, Message: "execution reverted", Data: "0x" + hex.EncodeToString(ret)}
+		}
+		return json.Marshal("0x" + hex.EncodeToString(ret))
+	}
+
+	n.aggregates++
+	in, err := multicall3.Methods[0].UnpackInputs(msg.Data)
+	if err != nil {
+		return nil, err
+	}
+	var results []any
+	for _, c := range in[0].([]any) {
+		call := c.([]any)
+		ret, ok := n.exec(call[0].(abi.Address), call[2].([]byte))
+		if !ok && !call[1].(bool) {
+			reason, _ := revertErrorArgs.Pack("Multicall3: call failed")
+			return nil, &ErrorObject{Code: 3, Message: "execution reverted", Data: "0x" + hex.EncodeToString(append(revertErrorSelector, reason...))}
+		}
+		results = append(results, []any{ok, ret})
+	}
+	out, err := multicall3.Methods[0].Outputs.Pack(results)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal("0x" + hex.EncodeToString(out))
+}
+
+func TestMulticall(t *testing.T) {
+	type call struct {
+		to           abi.Address
+		value        int64
+		allowFailure bool
+		gas          uint64
+	}
+	tests := []struct {
+		name        string
+		deployed    bool
+		maxCalldata int
+		maxGas      uint64
+		calls       []call
+		aggregates  int
+		individual  int
+		expect      []string // decoded value, or error
+	}{
+		{"aggregated", true, 0, 0, []call{{multicallTestGood, 1, true, 0}, {multicallTestBad, 2, true, 0}}, 1, 0, []string{"1", "execution reverted: Low[2]"}},
+		{"not deployed", false, 0, 0, []call{{multicallTestGood, 1, true, 0}, {multicallTestBad, 2, false, 0}}, 0, 2, []string{"1", "execution reverted: Low[2]"}},
+		{"failure not allowed", true, 0, 0, []call{{multicallTestGood, 1, true, 0}, {multicallTestBad, 2, false, 0}}, 1, 0, []string{"execution reverted: Multicall3: call failed", "execution reverted: Multicall3: call failed"}},
+		{"calldata limit", true, 500, 0, []call{{multicallTestGood, 1, true, 0}, {multicallTestGood, 2, true, 0}, {multicallTestGood, 3, true, 0}}, 2, 0, []string{"1", "2", "3"}},
+		{"calldata limit per call", true, 10, 0, []call{{multicallTestGood, 1, true, 0}, {multicallTestGood, 2, true, 0}}, 2, 0, []string{"1", "2"}},
+		{"gas limit", true, 0, 100, []call{{multicallTestGood, 1, true, 60}, {multicallTestGood, 2, true, 40}, {multicallTestGood, 3, true, 1}}, 2, 0, []string{"1", "2", "3"}},
+		{"empty", true, 0, 0, nil, 0, 0, nil},
+	}
+	for _, test := range tests {
+		node := &multicallTestNode{deployed: test.deployed}
+		api := &Api{node}
+		c := NewContract(multicallTestGood, multicallTestABI, api)
+		m := api.NewMulticall()
+		if test.maxCalldata > 0 {
+			m.MaxCalldata = test.maxCalldata
+		}
+		m.MaxGas = test.maxGas
+
+		values := make([]*big.Int, len(test.calls))
+		for n, spec := range test.calls {
+			c.Address = spec.to
+			call, err := m.AddCall(c, &values[n], "value", big.NewInt(spec.value))
+			if err != nil {
+				t.Fatalf("%s: AddCall: %s", test.name, err)
+			}
+			call.AllowFailure = spec.allowFailure
+			call.Gas = spec.gas
+		}
+		if err := m.Run(context.Background()); err != nil {
+			t.Errorf("%s: Run: %s", test.name, err)
+			continue
+		}
+		if node.aggregates != test.aggregates || node.calls != test.individual {
+			t.Errorf("%s: %d aggregate3 and %d individual calls", test.name, node.aggregates, node.calls)
+		}
+		for n, call := range m.Calls {
+			res := ""
+			if call.Err != nil {
+				res = call.Err.Error()
+			} else if values[n] != nil {
+				res = values[n].String()
+			}
+			if res != test.expect[n] || call.Success != (call.Err == nil) {
+				t.Errorf("%s: call %d got %s (success %v), expected %s", test.name, n, res, call.Success, test.expect[n])
+			}
+		}
+	}
+}
+
+func TestMulticallRaw(t *testing.T) {
+	node := &multicallTestNode{deployed: true}
+	m := (&Api{node}).NewMulticall()
+	data, _ := multicallTestABI.Method("value").Pack(big.NewInt(7))
+	call := m.Add(multicallTestBad, data)
+	if err := m.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	rev, ok := call.Err.(*RevertError)
+	if call.Success || !ok || rev.Custom != nil || hex.EncodeToString(rev.Data[:4]) != hex.EncodeToString(multicallTestABI.Errors[0].Selector()) {
+		t.Errorf("got %v, %v", call.Success, call.Err)
+	}
+
+	// the deployment check is done once
+	m.Run(context.Background())
+	node.deployed = false
+	if m.Run(context.Background()); node.aggregates != 3 {
+		t.Errorf("%d aggregate3 calls", node.aggregates)
+	}
+}
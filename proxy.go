@@ -0,0 +1,421 @@
+package ethrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KarpelesLab/typutil"
+)
+
+// JSON-RPC error codes
+const (
+	errParse          = -32700
+	errInvalidRequest = -32600
+	errMethodNotFound = -32601
+	errInvalidParams  = -32602
+	errInternal       = -32603
+	errLimitExceeded  = -32005
+)
+
+// ProxyOptions configures a [Proxy]
+type ProxyOptions struct {
+	AllowMethods []string        // if set, only these methods are accepted. A trailing * matches any suffix, as in "eth_*"
+	DenyMethods  []string        // methods that are refused, same format as AllowMethods
+	Overrides    map[string]any  // functions answering methods locally, see [RPC.Override]
+	CORSOrigin   string          // value of Access-Control-Allow-Origin, defaults to "*"
+	MaxBatch     int             // maximum number of requests in a batch, defaults to 100
+	MaxBodySize  int64           // maximum size of a request body, defaults to 1MB
+	Pretty       bool            // indent responses
+	RateLimit    *ProxyRateLimit // if set, limits the requests of each client
+	Auth         *ProxyAuth      // if set, clients must send an API key
+	ChainId      uint64          // chain served by the proxy, checked against the chains of API keys. Keys restricted to some chains are refused if not set.
+	Logger       *slog.Logger    // logs the errors hidden from clients, defaults to slog.Default()
+}
+
+// Proxy is a [http.Handler] serving JSON-RPC requests, answering overridden methods locally and
+// forwarding the others to a [Handler] such as [RPC] or [RPCList]
+type Proxy struct {
+	Handler Handler
+	Options ProxyOptions
+
+	override map[string]*typutil.Callable
+	mw       []Middleware
+	chain    RequestHandler
+	limits   RateLimitStore
+}
+
+// proxyRequest is a request as received by the proxy, keeping the raw id
+type proxyRequest struct {
+	JsonRpc string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	Id      json.RawMessage `json:"id"`
+}
+
+// isNotification returns true for valid requests without id, which get no response
+func (r *proxyRequest) isNotification() bool {
+	return r.Id == nil && r.JsonRpc == "2.0" && r.Method != ""
+}
+
+// proxyResponse is a response as sent by the proxy, result is omitted on errors
+type proxyResponse struct {
+	JsonRpc string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *ErrorObject    `json:"error,omitempty"`
+	Id      json.RawMessage `json:"id"`
+
+	status     int           // http status of single responses, if not 200
+	retryAfter time.Duration // set when refused because of rate limits
+}
+
+// proxyClient holds what is known of the client that sent a http request
+type proxyClient struct {
+	key         *APIKey
+	limitKey    string
+	rate, burst float64
+}
+
+// NewProxy returns a new [Proxy] forwarding requests to h. opts can be nil.
+func NewProxy(h Handler, opts *ProxyOptions) *Proxy {
+	p := &Proxy{Handler: h, override: make(map[string]*typutil.Callable)}
+	p.chain = RequestHandlerFunc(p.dispatch)
+	if opts != nil {
+		p.Options = *opts
+	}
+	if p.Options.CORSOrigin == "" {
+		p.Options.CORSOrigin = "*"
+	}
+	if p.Options.MaxBatch <= 0 {
+		p.Options.MaxBatch = 100
+	}
+	if p.Options.MaxBodySize <= 0 {
+		p.Options.MaxBodySize = 1 << 20
+	}
+	if p.Options.RateLimit != nil && p.Options.RateLimit.Store != nil {
+		p.limits = p.Options.RateLimit.Store
+	} else {
+		p.limits = NewMemoryRateLimitStore()
+	}
+	for method, fnc := range p.Options.Overrides {
+		p.Override(method, fnc)
+	}
+	return p
+}
+
+// Override allows answering calls to a RPC method with a standard go function instead of
+// forwarding them. Errors returned by the function are only sent to clients if they are
+// [ErrorObject] values. It must not be called while the proxy is serving requests.
+func (p *Proxy) Override(method string, fnc any) {
+	p.override[method] = typutil.Func(fnc)
+}
+
+// ServeHTTP handles single and batch JSON-RPC requests sent with POST, as well as single requests
+// sent with GET using the method, params and id query parameters. The span context found in the
+// traceparent header is propagated to the requests forwarded upstream.
+func (p *Proxy) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	ctx := ExtractTraceParent(req.Context(), req.Header)
+	h := rw.Header()
+	h.Set("Access-Control-Allow-Origin", p.Options.CORSOrigin)
+	h.Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+
+	if req.Method == http.MethodOptions {
+		allow := "Content-Type, Authorization, traceparent, tracestate"
+		if p.Options.Auth != nil {
+			allow += ", " + p.Options.Auth.header()
+		}
+		h.Set("Access-Control-Allow-Headers", allow)
+		h.Set("Access-Control-Max-Age", "86400")
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+	client, errObj, status := p.client(req)
+	if errObj != nil {
+		p.write(rw, &proxyResponse{JsonRpc: "2.0", Error: errObj, Id: json.RawMessage("null"), status: status})
+		return
+	}
+
+	var body []byte
+	switch req.Method {
+	case http.MethodGet:
+		q := req.URL.Query()
+		if q.Get("method") == "" {
+			p.write(rw, &proxyResponse{JsonRpc: "2.0", Error: &ErrorObject{Code: errInvalidRequest, Message: "missing method"}, Id: json.RawMessage("null")})
+			return
+		}
+		greq := &proxyRequest{JsonRpc: "2.0", Method: q.Get("method"), Id: json.RawMessage("1")}
+		if params := q.Get("params"); params != "" {
+			greq.Params = json.RawMessage(params)
+		}
+		if id := q.Get("id"); id != "" {
+			greq.Id = json.RawMessage(id)
+		}
+		var err error
+		if body, err = json.Marshal(greq); err != nil {
+			p.write(rw, &proxyResponse{JsonRpc: "2.0", Error: &ErrorObject{Code: errParse, Message: "parse error"}, Id: json.RawMessage("null")})
+			return
+		}
+	case http.MethodPost:
+		var err error
+		body, err = io.ReadAll(http.MaxBytesReader(rw, req.Body, p.Options.MaxBodySize))
+		if err != nil {
+			p.write(rw, &proxyResponse{JsonRpc: "2.0", Error: &ErrorObject{Code: errInvalidRequest, Message: err.Error()}, Id: json.RawMessage("null")})
+			return
+		}
+	default:
+		h.Set("Allow", "GET, POST, OPTIONS")
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 || body[0] != '[' {
+		if !json.Valid(body) {
+			p.write(rw, &proxyResponse{JsonRpc: "2.0", Error: &ErrorObject{Code: errParse, Message: "parse error"}, Id: json.RawMessage("null")})
+			return
+		}
+		// valid json that is not a request object, such as 1, "x" or null
+		var r *proxyRequest
+		if err := json.Unmarshal(body, &r); err != nil || r == nil || body[0] != '{' {
+			p.write(rw, &proxyResponse{JsonRpc: "2.0", Error: &ErrorObject{Code: errInvalidRequest, Message: "invalid request"}, Id: json.RawMessage("null")})
+			return
+		}
+		res := p.serve(ctx, client, r)
+		if r.isNotification() {
+			// notification
+			rw.WriteHeader(http.StatusNoContent)
+			return
+		}
+		p.write(rw, res)
+		return
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		p.write(rw, &proxyResponse{JsonRpc: "2.0", Error: &ErrorObject{Code: errParse, Message: "parse error"}, Id: json.RawMessage("null")})
+		return
+	}
+	if len(batch) == 0 || len(batch) > p.Options.MaxBatch {
+		msg := "empty batch"
+		if len(batch) > 0 {
+			msg = fmt.Sprintf("batch is limited to %d requests", p.Options.MaxBatch)
+		}
+		p.write(rw, &proxyResponse{JsonRpc: "2.0", Error: &ErrorObject{Code: errInvalidRequest, Message: msg}, Id: json.RawMessage("null")})
+		return
+	}
+
+	results := make([]*proxyResponse, len(batch))
+	var wg sync.WaitGroup
+	for n, raw := range batch {
+		var r *proxyRequest
+		if err := json.Unmarshal(raw, &r); err != nil || r == nil {
+			results[n] = &proxyResponse{JsonRpc: "2.0", Error: &ErrorObject{Code: errInvalidRequest, Message: "invalid request"}, Id: json.RawMessage("null")}
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := p.serve(ctx, client, r)
+			if !r.isNotification() {
+				results[n] = res
+			}
+		}()
+	}
+	wg.Wait()
+
+	var out []*proxyResponse
+	for _, res := range results {
+		if res != nil {
+			out = append(out, res)
+		}
+	}
+	if len(out) == 0 {
+		// only notifications
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+	p.write(rw, out)
+}
+
+// client returns the client that sent req, or an error and the http status to respond with if
+// it failed to authenticate
+func (p *Proxy) client(req *http.Request) (*proxyClient, *ErrorObject, int) {
+	c := &proxyClient{}
+	if l := p.Options.RateLimit; l != nil {
+		c.limitKey, c.rate, c.burst = l.key(req), l.Rate, l.Burst
+	}
+	if a := p.Options.Auth; a != nil {
+		key, obj, status := a.authenticate(req, p.Options.ChainId)
+		if obj != nil {
+			return nil, obj, status
+		}
+		c.key = key
+		if key.Rate > 0 {
+			// the rate of the key replaces the default limit
+			c.limitKey, c.rate, c.burst = "apikey:"+key.limitId(), key.Rate, key.Burst
+		}
+	}
+	if c.burst <= 0 {
+		c.burst = c.rate
+	}
+	return c, nil, 0
+}
+
+// serve processes a single request
+func (p *Proxy) serve(ctx context.Context, client *proxyClient, r *proxyRequest) *proxyResponse {
+	res := &proxyResponse{JsonRpc: "2.0", Id: r.Id}
+	if res.Id == nil {
+		res.Id = json.RawMessage("null")
+	}
+	if r.JsonRpc != "2.0" || r.Method == "" {
+		res.Error = &ErrorObject{Code: errInvalidRequest, Message: "invalid request"}
+		return res
+	}
+	if !p.allowed(r.Method) || (client.key != nil && !client.key.allowed(r.Method)) {
+		res.Error = &ErrorObject{Code: errMethodNotFound, Message: fmt.Sprintf("method %s is not available", r.Method)}
+		return res
+	}
+	if res.Error, res.retryAfter = p.limit(ctx, client, r.Method); res.Error != nil {
+		if res.retryAfter > 0 {
+			res.status = http.StatusTooManyRequests
+		}
+		return res
+	}
+
+	var params any
+	if len(r.Params) > 0 && string(r.Params) != "null" {
+		dec := json.NewDecoder(bytes.NewReader(r.Params))
+		dec.UseNumber()
+		if err := dec.Decode(&params); err != nil {
+			res.Error = &ErrorObject{Code: errInvalidParams, Message: "invalid params"}
+			return res
+		}
+	}
+	switch params.(type) {
+	case nil:
+		params = []any{}
+	case []any, map[string]any:
+	default:
+		res.Error = &ErrorObject{Code: errInvalidParams, Message: "params must be an array or an object"}
+		return res
+	}
+
+	req := &Request{JsonRpc: "2.0", Method: r.Method, Params: params, Id: r.Id}
+	result, err := p.chain.SendCtx(ctx, req)
+	if err != nil {
+		res.Error = p.proxyError(ctx, r.Method, err)
+		return res
+	}
+	res.Result = result
+	return res
+}
+
+// dispatch answers overridden methods and forwards the other requests to the handler
+func (p *Proxy) dispatch(ctx context.Context, req *Request) (json.RawMessage, error) {
+	f, ok := p.override[req.Method]
+	if !ok {
+		return AsRequestHandler(p.Handler).SendCtx(ctx, req)
+	}
+	params, ok := req.Params.([]any)
+	if !ok {
+		return nil, &ErrorObject{Code: errInvalidParams, Message: "method only supports positional params"}
+	}
+	result, err := f.CallArg(ctx, params...)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(result)
+}
+
+// Use adds middlewares processing the requests received by the proxy, including overridden
+// methods. Middlewares added first are the outermost ones. Use must not be called while the
+// proxy is serving requests.
+func (p *Proxy) Use(mw ...Middleware) {
+	p.mw = append(p.mw, mw...)
+	p.chain = chainMiddleware(RequestHandlerFunc(p.dispatch), p.mw)
+}
+
+// proxyError returns the json-rpc error matching err, keeping errors returned by the upstream
+// server as is. Other errors may contain upstream urls and keys, they are logged and replaced by
+// a generic error.
+func (p *Proxy) proxyError(ctx context.Context, method string, err error) *ErrorObject {
+	var obj *ErrorObject
+	if errors.As(err, &obj) {
+		return obj
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return &ErrorObject{Code: errInternal, Message: "request cancelled"}
+	}
+	p.logger().ErrorContext(ctx, "proxy request failed", slog.String("method", method), slog.Any("error", err))
+	return &ErrorObject{Code: errInternal, Message: "internal error"}
+}
+
+func (p *Proxy) logger() *slog.Logger {
+	if p.Options.Logger != nil {
+		return p.Options.Logger
+	}
+	return slog.Default()
+}
+
+// allowed returns true if the method passes the allow and deny lists
+func (p *Proxy) allowed(method string) bool {
+	return methodAllowed(p.Options.AllowMethods, p.Options.DenyMethods, method)
+}
+
+// methodAllowed returns true if method is in allow (or allow is empty) and not in deny
+func methodAllowed(allow, deny []string, method string) bool {
+	if len(allow) > 0 && !matchMethod(allow, method) {
+		return false
+	}
+	return !matchMethod(deny, method)
+}
+
+func matchMethod(list []string, method string) bool {
+	for _, m := range list {
+		if prefix, ok := strings.CutSuffix(m, "*"); ok {
+			if strings.HasPrefix(method, prefix) {
+				return true
+			}
+		} else if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// write sends v as response. If requests were refused because of rate limits, a Retry-After
+// header is added.
+func (p *Proxy) write(rw http.ResponseWriter, v any) {
+	rw.Header().Set("Content-Type", "application/json")
+	var retryAfter time.Duration
+	status := 0
+	switch res := v.(type) {
+	case *proxyResponse:
+		retryAfter, status = res.retryAfter, res.status
+	case []*proxyResponse:
+		for _, r := range res {
+			retryAfter = max(retryAfter, r.retryAfter)
+		}
+	}
+	if retryAfter > 0 {
+		rw.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	}
+	if status != 0 {
+		rw.WriteHeader(status)
+	}
+	enc := json.NewEncoder(rw)
+	if p.Options.Pretty {
+		enc.SetIndent("", "    ")
+	}
+	enc.Encode(v)
+}
@@ -0,0 +1,89 @@
+package ethrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// proxyTestUpstream is a handler answering eth_blockNumber, echoing the params of echo and
+// failing for the other methods
+var proxyTestUpstream = RequestHandlerFunc(func(ctx context.Context, req *Request) (json.RawMessage, error) {
+	switch req.Method {
+	case "eth_blockNumber":
+		return json.RawMessage(`"0x10"`), nil
+	case "echo":
+		return json.Marshal(req.Params)
+	case "eth_call":
+		return nil, &ErrorObject{Code: 3, Message: "execution reverted", Data: "0x"}
+	}
+	return nil, errors.New(`Post "https://upstream.example.com/v2/secretkey": connection refused`)
+})
+
+// proxyTestDo sends a request to p and returns the response
+func proxyTestDo(p http.Handler, method, target, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.RemoteAddr = "192.0.2.1:1234"
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rw := httptest.NewRecorder()
+	p.ServeHTTP(rw, req)
+	return rw
+}
+
+func TestProxy(t *testing.T) {
+	logs := &bytes.Buffer{}
+	p := NewProxy(proxyTestUpstream, &ProxyOptions{
+		DenyMethods: []string{"admin_*"},
+		Overrides:   map[string]any{"eth_chainId": func() (string, error) { return "0x1", nil }},
+		MaxBatch:    3,
+		Logger:      slog.New(slog.NewTextHandler(logs, nil)),
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		expect string
+	}{
+		{"single", "POST", "/", `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}`, 200, `{"jsonrpc":"2.0","result":"0x10","id":1}`},
+		{"params", "POST", "/", `{"jsonrpc":"2.0","id":"a","method":"echo","params":[1,"x",{"b":true}]}`, 200, `{"jsonrpc":"2.0","result":[1,"x",{"b":true}],"id":"a"}`},
+		{"override", "POST", "/", `{"jsonrpc":"2.0","id":2,"method":"eth_chainId"}`, 200, `{"jsonrpc":"2.0","result":"0x1","id":2}`},
+		{"get", "GET", "/?method=echo&params=%5B2%5D&id=5", "", 200, `{"jsonrpc":"2.0","result":[2],"id":5}`},
+		{"notification", "POST", "/", `{"jsonrpc":"2.0","method":"eth_blockNumber"}`, 204, ``},
+		{"batch", "POST", "/", `[{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"},{"jsonrpc":"2.0","method":"eth_blockNumber"},1]`, 200, `[{"jsonrpc":"2.0","result":"0x10","id":1},{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid request"},"id":null}]`},
+		{"batch too large", "POST", "/", `[1,2,3,4]`, 200, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"batch is limited to 3 requests"},"id":null}`},
+		{"empty batch", "POST", "/", `[]`, 200, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"empty batch"},"id":null}`},
+		{"parse error", "POST", "/", `{"jsonrpc":`, 200, `{"jsonrpc":"2.0","error":{"code":-32700,"message":"parse error"},"id":null}`},
+		{"not an object", "POST", "/", `"x"`, 200, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid request"},"id":null}`},
+		{"invalid version", "POST", "/", `{"jsonrpc":"1.0","id":1,"method":"eth_blockNumber"}`, 200, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid request"},"id":1}`},
+		{"invalid params", "POST", "/", `{"jsonrpc":"2.0","id":1,"method":"echo","params":3}`, 200, `{"jsonrpc":"2.0","error":{"code":-32602,"message":"params must be an array or an object"},"id":1}`},
+		{"denied", "POST", "/", `{"jsonrpc":"2.0","id":1,"method":"admin_peers"}`, 200, `{"jsonrpc":"2.0","error":{"code":-32601,"message":"method admin_peers is not available"},"id":1}`},
+		{"upstream error", "POST", "/", `{"jsonrpc":"2.0","id":1,"method":"eth_call"}`, 200, `{"jsonrpc":"2.0","error":{"code":3,"message":"execution reverted","data":"0x"},"id":1}`},
+		{"internal error", "POST", "/", `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance"}`, 200, `{"jsonrpc":"2.0","error":{"code":-32603,"message":"internal error"},"id":1}`},
+		{"http method", "PUT", "/", ``, 405, ``},
+	}
+	for _, test := range tests {
+		rw := proxyTestDo(p, test.method, test.target, test.body, nil)
+		if rw.Code != test.status {
+			t.Errorf("%s: got status %d, expected %d", test.name, rw.Code, test.status)
+		}
+		if res := strings.TrimSpace(rw.Body.String()); res != test.expect {
+			t.Errorf("%s: got %s, expected %s", test.name, res, test.expect)
+		}
+	}
+
+	// hidden errors are logged
+	if !strings.Contains(logs.String(), "secretkey") || !strings.Contains(logs.String(), "eth_getBalance") {
+		t.Errorf("internal error was not logged: %s", logs)
+	}
+}
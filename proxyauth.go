@@ -0,0 +1,195 @@
+package ethrpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProxyAuth requires the clients of a [Proxy] to send an API key, in a header, a query parameter
+// or the last segment of the url path. Bearer tokens in the Authorization header are accepted too.
+type ProxyAuth struct {
+	Header      string   // header holding the key, defaults to X-Api-Key
+	Query       string   // query parameter holding the key, disabled if empty
+	PathSegment bool     // accept the key as the last segment of the url path, as in /v1/<key>
+	Keys        *APIKeys // known keys
+}
+
+// APIKey describes what a client of a [Proxy] is allowed to do. Rate and Burst limit how fast
+// the key can send requests, while Quota limits the tokens it uses per period, such as a number
+// of requests per day. Both use the method costs of [ProxyOptions].RateLimit.
+type APIKey struct {
+	Key          string   `json:"key"`
+	Name         string   `json:"name,omitempty"`
+	AllowMethods []string `json:"allow_methods,omitempty"` // if set, only these methods are accepted. A trailing * matches any suffix, as in "eth_*"
+	DenyMethods  []string `json:"deny_methods,omitempty"`  // methods that are refused, as in "debug_*", "admin_*" or "personal_*"
+	Chains       []uint64 `json:"chains,omitempty"`        // chain ids the key can be used with, see [ProxyOptions].ChainId. All if empty.
+	Rate         float64  `json:"rate,omitempty"`          // if set, requests of the key are limited to this many tokens per second
+	Burst        float64  `json:"burst,omitempty"`         // maximum number of tokens, defaults to Rate
+	Quota        float64  `json:"quota,omitempty"`         // if set, tokens the key can use per quota period
+	QuotaPeriod  int64    `json:"quota_period,omitempty"`  // length of the quota period in seconds, defaults to a day
+}
+
+// APIKeys is a set of [APIKey] that can be replaced while in use
+type APIKeys struct {
+	lk   sync.RWMutex
+	keys map[string]*APIKey
+}
+
+// apiKeysFile is the format of files read by [APIKeys.LoadFile]
+type apiKeysFile struct {
+	Keys []*APIKey `json:"keys"`
+}
+
+// NewAPIKeys returns a new [APIKeys] holding the given keys
+func NewAPIKeys(keys ...*APIKey) *APIKeys {
+	k := &APIKeys{}
+	k.Set(keys...)
+	return k
+}
+
+// Get returns the key with the given value, or nil if it does not exist
+func (k *APIKeys) Get(key string) *APIKey {
+	k.lk.RLock()
+	defer k.lk.RUnlock()
+	return k.keys[key]
+}
+
+// Set replaces the keys
+func (k *APIKeys) Set(keys ...*APIKey) {
+	m := make(map[string]*APIKey, len(keys))
+	for _, key := range keys {
+		if key.Key != "" {
+			m[key.Key] = key
+		}
+	}
+	k.lk.Lock()
+	defer k.lk.Unlock()
+	k.keys = m
+}
+
+// LoadFile replaces the keys with the ones in a json file, such as:
+//
+//	{"keys": [{"key": "secret", "name": "frontend", "deny_methods": ["debug_*", "admin_*"], "chains": [1], "rate": 10, "quota": 100000}]}
+func (k *APIKeys) LoadFile(name string) error {
+	buf, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	var f apiKeysFile
+	if err := json.Unmarshal(buf, &f); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	k.Set(f.Keys...)
+	return nil
+}
+
+// Watch loads the keys from a json file, see [APIKeys.LoadFile], and reloads them when the file
+// is modified, checking every interval until ctx is cancelled. interval defaults to 10 seconds.
+// If a reload fails, the current keys are kept.
+func (k *APIKeys) Watch(ctx context.Context, name string, interval time.Duration) error {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	st, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	if err := k.LoadFile(name); err != nil {
+		return err
+	}
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+			cur, err := os.Stat(name)
+			if err != nil || (cur.ModTime().Equal(st.ModTime()) && cur.Size() == st.Size()) {
+				continue
+			}
+			if k.LoadFile(name) == nil {
+				st = cur
+			}
+		}
+	}()
+	return nil
+}
+
+func (a *ProxyAuth) header() string {
+	if a.Header == "" {
+		return "X-Api-Key"
+	}
+	return a.Header
+}
+
+// key returns the API key sent with req, if any
+func (a *ProxyAuth) key(req *http.Request) string {
+	if v := req.Header.Get(a.header()); v != "" {
+		return v
+	}
+	if v, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer "); ok && v != "" {
+		return v
+	}
+	if a.Query != "" {
+		if v := req.URL.Query().Get(a.Query); v != "" {
+			return v
+		}
+	}
+	if a.PathSegment {
+		if v := path.Base(req.URL.Path); v != "/" && v != "." {
+			return v
+		}
+	}
+	return ""
+}
+
+// authenticate returns the key of the client that sent req, or an error and the http status to
+// respond with
+func (a *ProxyAuth) authenticate(req *http.Request, chainId uint64) (*APIKey, *ErrorObject, int) {
+	var key *APIKey
+	if v := a.key(req); v != "" && a.Keys != nil {
+		key = a.Keys.Get(v)
+	}
+	if key == nil {
+		return nil, &ErrorObject{Code: errInvalidRequest, Message: "missing or invalid api key"}, http.StatusUnauthorized
+	}
+	if len(key.Chains) > 0 && chainId == 0 {
+		// fail closed if the chain served by the proxy is not known
+		return nil, &ErrorObject{Code: errInvalidRequest, Message: "api key is restricted to some chains but the chain of the proxy is not configured"}, http.StatusForbidden
+	}
+	if len(key.Chains) > 0 && !slices.Contains(key.Chains, chainId) {
+		return nil, &ErrorObject{Code: errInvalidRequest, Message: fmt.Sprintf("api key is not allowed on chain %d", chainId)}, http.StatusForbidden
+	}
+	return key, nil, 0
+}
+
+// limitId identifies the key in a [RateLimitStore] without storing its value
+func (k *APIKey) limitId() string {
+	sum := sha256.Sum256([]byte(k.Key))
+	return hex.EncodeToString(sum[:16])
+}
+
+func (k *APIKey) quotaPeriod() time.Duration {
+	if k.QuotaPeriod <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(k.QuotaPeriod) * time.Second
+}
+
+// allowed returns true if the key can call method
+func (k *APIKey) allowed(method string) bool {
+	return methodAllowed(k.AllowMethods, k.DenyMethods, method)
+}
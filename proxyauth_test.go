@@ -0,0 +1,147 @@
+package ethrpc
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProxyAuth(t *testing.T) {
+	keys := NewAPIKeys(
+		&APIKey{Key: "secret"},
+		&APIKey{Key: "readonly", AllowMethods: []string{"eth_*"}, DenyMethods: []string{"eth_sendRawTransaction"}},
+		&APIKey{Key: "mainnet", Chains: []uint64{1}},
+		&APIKey{Key: "testnet", Chains: []uint64{11155111}},
+	)
+	opts := &ProxyOptions{
+		DenyMethods: []string{"admin_*"},
+		Overrides:   map[string]any{"net_version": func() (string, error) { return "1", nil }, "eth_sendRawTransaction": func(tx string) (string, error) { return "0x01", nil }},
+		Auth:        &ProxyAuth{Query: "key", PathSegment: true, Keys: keys},
+		ChainId:     1,
+	}
+	p := NewProxy(proxyTestUpstream, opts)
+	noChain := *opts
+	noChain.ChainId = 0
+	pNoChain := NewProxy(proxyTestUpstream, &noChain)
+
+	const (
+		ok      = `{"jsonrpc":"2.0","result":"0x10","id":1}`
+		invalid = `{"jsonrpc":"2.0","error":{"code":-32600,"message":"missing or invalid api key"},"id":null}`
+	)
+	tests := []struct {
+		name   string
+		proxy  *Proxy
+		target string
+		header http.Header
+		method string
+		status int
+		expect string
+	}{
+		{"header", p, "/", http.Header{"X-Api-Key": {"secret"}}, "eth_blockNumber", 200, ok},
+		{"bearer", p, "/", http.Header{"Authorization": {"Bearer secret"}}, "eth_blockNumber", 200, ok},
+		{"query", p, "/?key=secret", nil, "eth_blockNumber", 200, ok},
+		{"path", p, "/v1/secret", nil, "eth_blockNumber", 200, ok},
+		{"missing", p, "/", nil, "eth_blockNumber", 401, invalid},
+		{"unknown", p, "/v1/random", http.Header{"Authorization": {"Basic c2VjcmV0"}}, "eth_blockNumber", 401, invalid},
+		{"proxy deny", p, "/", http.Header{"X-Api-Key": {"secret"}}, "admin_peers", 200, `{"jsonrpc":"2.0","error":{"code":-32601,"message":"method admin_peers is not available"},"id":1}`},
+		{"key allow", p, "/", http.Header{"X-Api-Key": {"readonly"}}, "eth_blockNumber", 200, ok},
+		{"key allow miss", p, "/", http.Header{"X-Api-Key": {"readonly"}}, "net_version", 200, `{"jsonrpc":"2.0","error":{"code":-32601,"message":"method net_version is not available"},"id":1}`},
+		{"key deny", p, "/", http.Header{"X-Api-Key": {"readonly"}}, "eth_sendRawTransaction", 200, `{"jsonrpc":"2.0","error":{"code":-32601,"message":"method eth_sendRawTransaction is not available"},"id":1}`},
+		{"other key", p, "/", http.Header{"X-Api-Key": {"secret"}}, "net_version", 200, `{"jsonrpc":"2.0","result":"1","id":1}`},
+		{"chain", p, "/", http.Header{"X-Api-Key": {"mainnet"}}, "eth_blockNumber", 200, ok},
+		{"other chain", p, "/", http.Header{"X-Api-Key": {"testnet"}}, "eth_blockNumber", 403, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"api key is not allowed on chain 1"},"id":null}`},
+		{"unknown chain", pNoChain, "/", http.Header{"X-Api-Key": {"mainnet"}}, "eth_blockNumber", 403, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"api key is restricted to some chains but the chain of the proxy is not configured"},"id":null}`},
+		{"unrestricted key", pNoChain, "/", http.Header{"X-Api-Key": {"secret"}}, "eth_blockNumber", 200, ok},
+	}
+	for _, test := range tests {
+		params := "[]"
+		if test.method == "eth_sendRawTransaction" {
+			params = `["0x00"]`
+		}
+		body := `{"jsonrpc":"2.0","id":1,"method":"` + test.method + `","params":` + params + `}`
+		rw := proxyTestDo(test.proxy, "POST", test.target, body, test.header)
+		if rw.Code != test.status {
+			t.Errorf("%s: got status %d, expected %d", test.name, rw.Code, test.status)
+		}
+		if res := strings.TrimSpace(rw.Body.String()); res != test.expect {
+			t.Errorf("%s: got %s, expected %s", test.name, res, test.expect)
+		}
+	}
+}
+
+func TestMethodAllowed(t *testing.T) {
+	tests := []struct {
+		allow  []string
+		deny   []string
+		method string
+		expect bool
+	}{
+		{nil, nil, "eth_call", true},
+		{[]string{"eth_call"}, nil, "eth_call", true},
+		{[]string{"eth_call"}, nil, "eth_callMany", false},
+		{[]string{"eth_*"}, nil, "eth_getLogs", true},
+		{[]string{"eth_*"}, nil, "net_version", false},
+		{nil, []string{"debug_*"}, "debug_traceTransaction", false},
+		{nil, []string{"debug_*"}, "eth_call", true},
+		{[]string{"eth_*"}, []string{"eth_sign*"}, "eth_signTransaction", false},
+		{[]string{"*"}, nil, "anything", true},
+	}
+	for _, test := range tests {
+		if res := methodAllowed(test.allow, test.deny, test.method); res != test.expect {
+			t.Errorf("methodAllowed(%v, %v, %s) = %v", test.allow, test.deny, test.method, res)
+		}
+	}
+}
+
+func TestAPIKeysWatch(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "keys.json")
+	if err := os.WriteFile(name, []byte(`{"keys": [{"key": "a", "rate": 10}]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	keys := &APIKeys{}
+	if err := keys.Watch(ctx, name, 0); err != nil {
+		t.Fatalf("Watch: %s", err)
+	}
+	if k := keys.Get("a"); k == nil || k.Rate != 10 {
+		t.Fatalf("key a = %+v", k)
+	}
+	if err := keys.Watch(ctx, filepath.Join(t.TempDir(), "missing.json"), time.Millisecond); err == nil {
+		t.Errorf("watching a missing file succeeded")
+	}
+
+	keys = &APIKeys{}
+	if err := keys.Watch(ctx, name, 10*time.Millisecond); err != nil {
+		t.Fatalf("Watch: %s", err)
+	}
+	steps := []struct {
+		content string
+		present string
+		absent  string
+	}{
+		{`{"keys": [{"key": "b"}, {"key": ""}]}`, "b", "a"},
+		{`{"keys": [`, "b", "a"}, // invalid files are ignored
+		{`{"keys": [{"key": "c", "chains": [1, 10]}]}`, "c", "b"},
+	}
+	for n, step := range steps {
+		if err := os.WriteFile(name, []byte(step.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		// make sure the modification is seen even if the time did not change
+		os.Chtimes(name, time.Now(), time.Now().Add(time.Duration(n+1)*time.Second))
+		deadline := time.Now().Add(2 * time.Second)
+		for keys.Get(step.present) == nil && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		time.Sleep(30 * time.Millisecond)
+		if keys.Get(step.present) == nil || keys.Get(step.absent) != nil || keys.Get("") != nil {
+			t.Errorf("step %d: keys were not reloaded", n)
+		}
+	}
+}
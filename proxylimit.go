@@ -0,0 +1,184 @@
+package ethrpc
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ProxyRateLimit limits the requests each client of a [Proxy] can send. Every json-rpc request,
+// including the ones in batches, consumes the cost of its method from the client's token bucket.
+// Refused requests get a -32005 error and the response a Retry-After header.
+type ProxyRateLimit struct {
+	Rate  float64                    // tokens added per second
+	Burst float64                    // maximum number of tokens, defaults to Rate
+	Costs map[string]float64         // cost of methods, others cost 1
+	Key   func(*http.Request) string // identifies clients, defaults to ClientIP. See RateLimitByAPIKey
+	Store RateLimitStore             // holds the token buckets, defaults to an in-memory store
+}
+
+// RateLimitStore holds the token buckets of a [ProxyRateLimit] and the quota counters of
+// [APIKey]. A shared store allows several proxies to apply the same limits.
+type RateLimitStore interface {
+	// Take removes cost tokens from the bucket of key, which is refilled at rate tokens per
+	// second up to burst. If not enough tokens are available, nothing is removed and Take returns
+	// how long to wait until they are.
+	Take(ctx context.Context, key string, cost, rate, burst float64) (time.Duration, error)
+
+	// Count adds cost to the counter of key for the current period. Periods start at multiples
+	// of period since the zero time, at midnight UTC for a day. If the counter would go over
+	// limit, nothing is added and Count returns how long until the period ends.
+	Count(ctx context.Context, key string, cost, limit float64, period time.Duration) (time.Duration, error)
+}
+
+// MemoryRateLimitStore is a [RateLimitStore] keeping token buckets and counters in memory.
+// Buckets of idle clients are removed once full, and counters once their period ended.
+type MemoryRateLimitStore struct {
+	lk       sync.Mutex
+	buckets  map[string]*memoryBucket
+	counters map[string]*memoryCounter
+	swept    time.Time
+}
+
+type memoryBucket struct {
+	tokenBucket
+	rate, burst float64
+}
+
+type memoryCounter struct {
+	end  time.Time
+	used float64
+}
+
+// NewMemoryRateLimitStore returns a new [MemoryRateLimitStore]
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[string]*memoryBucket), counters: make(map[string]*memoryCounter)}
+}
+
+// Take implements [RateLimitStore]
+func (s *MemoryRateLimitStore) Take(ctx context.Context, key string, cost, rate, burst float64) (time.Duration, error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	now := time.Now()
+	if now.Sub(s.swept) > time.Minute {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{}
+		s.buckets[key] = b
+	}
+	b.rate, b.burst = rate, burst
+	return b.take(now, cost, rate, burst), nil
+}
+
+// Count implements [RateLimitStore]
+func (s *MemoryRateLimitStore) Count(ctx context.Context, key string, cost, limit float64, period time.Duration) (time.Duration, error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	now := time.Now()
+	if now.Sub(s.swept) > time.Minute {
+		s.sweep(now)
+	}
+
+	c, ok := s.counters[key]
+	if !ok || !now.Before(c.end) {
+		c = &memoryCounter{end: now.Truncate(period).Add(period)}
+		s.counters[key] = c
+	}
+	if c.used+cost > limit {
+		return c.end.Sub(now), nil
+	}
+	c.used += cost
+	return 0, nil
+}
+
+// sweep removes the buckets that would be full by now and the counters of past periods
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	for k, b := range s.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*b.rate >= b.burst {
+			delete(s.buckets, k)
+		}
+	}
+	for k, c := range s.counters {
+		if !now.Before(c.end) {
+			delete(s.counters, k)
+		}
+	}
+	s.swept = now
+}
+
+// ClientIP returns the IP address of the client that sent req. Headers set by reverse proxies
+// are not trusted, use a custom key function when running behind one.
+func ClientIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+// RateLimitByAPIKey returns a key function identifying clients by the API key they send, found
+// the same way as auth does. Clients sending no key or a key that is not in auth.Keys are
+// identified by their IP, so that random values cannot be used to get a new bucket.
+func RateLimitByAPIKey(auth *ProxyAuth) func(*http.Request) string {
+	return func(req *http.Request) string {
+		if v := auth.key(req); v != "" && auth.Keys != nil {
+			if key := auth.Keys.Get(v); key != nil {
+				return "apikey:" + key.limitId()
+			}
+		}
+		return "ip:" + ClientIP(req)
+	}
+}
+
+func (l *ProxyRateLimit) key(req *http.Request) string {
+	if l.Key != nil {
+		return l.Key(req)
+	}
+	return ClientIP(req)
+}
+
+// limit consumes the cost of method from the bucket and the quota of the client, returning a
+// json-rpc error and the delay to wait if the client is over its limits
+func (p *Proxy) limit(ctx context.Context, client *proxyClient, method string) (*ErrorObject, time.Duration) {
+	cost := 1.0
+	if l := p.Options.RateLimit; l != nil {
+		if c, ok := l.Costs[method]; ok {
+			cost = c
+		}
+	}
+	if client.rate > 0 {
+		d, err := p.limits.Take(ctx, client.limitKey, cost, client.rate, client.burst)
+		if err != nil {
+			p.logger().ErrorContext(ctx, "rate limit store failed", slog.Any("error", err))
+			return &ErrorObject{Code: errInternal, Message: "rate limit unavailable"}, 0
+		}
+		if d > 0 {
+			return limitExceeded("rate limit exceeded", d), d
+		}
+	}
+	if key := client.key; key != nil && key.Quota > 0 {
+		d, err := p.limits.Count(ctx, "quota:"+key.limitId(), cost, key.Quota, key.quotaPeriod())
+		if err != nil {
+			p.logger().ErrorContext(ctx, "rate limit store failed", slog.Any("error", err))
+			return &ErrorObject{Code: errInternal, Message: "rate limit unavailable"}, 0
+		}
+		if d > 0 {
+			return limitExceeded("quota exceeded", d), d
+		}
+	}
+	return nil, 0
+}
+
+// limitExceeded returns the error sent to clients that have to wait for d
+func limitExceeded(msg string, d time.Duration) *ErrorObject {
+	secs := int(math.Ceil(d.Seconds()))
+	return &ErrorObject{Code: errLimitExceeded, Message: msg + ", retry in " + strconv.Itoa(secs) + "s", Data: map[string]any{"retryAfter": secs}}
+}
@@ -0,0 +1,189 @@
+package ethrpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimitByAPIKey(t *testing.T) {
+	auth := &ProxyAuth{Query: "key", PathSegment: true, Keys: NewAPIKeys(&APIKey{Key: "secret"})}
+	limitKey := "apikey:" + auth.Keys.Get("secret").limitId()
+	keyFunc := RateLimitByAPIKey(auth)
+
+	tests := []struct {
+		name   string
+		target string
+		header http.Header
+		expect string
+	}{
+		{"header", "/", http.Header{"X-Api-Key": {"secret"}}, limitKey},
+		{"bearer", "/", http.Header{"Authorization": {"Bearer secret"}}, limitKey},
+		{"query", "/?key=secret", nil, limitKey},
+		{"path", "/v1/secret", nil, limitKey},
+		{"unknown key", "/", http.Header{"X-Api-Key": {"random"}}, "ip:192.0.2.1"},
+		{"no key", "/", nil, "ip:192.0.2.1"},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest("POST", test.target, nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		for k, v := range test.header {
+			req.Header[k] = v
+		}
+		if res := keyFunc(req); res != test.expect {
+			t.Errorf("%s: got key %s, expected %s", test.name, res, test.expect)
+		}
+	}
+	if strings.Contains(limitKey, "secret") {
+		t.Errorf("limit key %s holds the api key", limitKey)
+	}
+
+	if res := RateLimitByAPIKey(&ProxyAuth{})(httptest.NewRequest("GET", "/?key=x", nil)); res != "ip:192.0.2.1" {
+		t.Errorf("no keys: got %s", res)
+	}
+}
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	s := NewMemoryRateLimitStore()
+	ctx := context.Background()
+	tests := []struct {
+		key    string
+		cost   float64
+		refuse bool
+	}{
+		{"a", 1, false},
+		{"a", 1, false},
+		{"a", 1, true},
+		{"b", 2, false},
+		{"a", 1, true}, // refused requests take nothing
+		{"b", 1, true},
+	}
+	for n, test := range tests {
+		d, err := s.Take(ctx, test.key, test.cost, 0.01, 2)
+		if err != nil || (d > 0) != test.refuse {
+			t.Errorf("take %d from %s: got %s, %v", n, test.key, d, err)
+		}
+	}
+}
+
+func TestMemoryRateLimitStoreCount(t *testing.T) {
+	s := NewMemoryRateLimitStore()
+	ctx := context.Background()
+	tests := []struct {
+		key    string
+		cost   float64
+		refuse bool
+	}{
+		{"a", 2, false},
+		{"a", 1, false},
+		{"a", 1, true},
+		{"a", 0, false},
+		{"b", 4, true}, // over the limit on its own
+		{"b", 3, false},
+	}
+	for n, test := range tests {
+		d, err := s.Count(ctx, test.key, test.cost, 3, time.Hour)
+		if err != nil || (d > 0) != test.refuse || d > time.Hour {
+			t.Errorf("count %d for %s: got %s, %v", n, test.key, d, err)
+		}
+	}
+
+	// counters restart with the next period
+	d, _ := s.Count(ctx, "c", 1, 1, 20*time.Millisecond)
+	if d, _ = s.Count(ctx, "c", 1, 1, 20*time.Millisecond); d <= 0 || d > 20*time.Millisecond {
+		t.Fatalf("second count in the period: %s", d)
+	}
+	time.Sleep(d)
+	if d, _ = s.Count(ctx, "c", 1, 1, 20*time.Millisecond); d != 0 {
+		t.Errorf("count in the next period: %s", d)
+	}
+}
+
+func TestProxyQuota(t *testing.T) {
+	auth := &ProxyAuth{Keys: NewAPIKeys(
+		&APIKey{Key: "a", Quota: 3},
+		&APIKey{Key: "b", Quota: 3, Rate: 100},
+		&APIKey{Key: "c"},
+	)}
+	p := NewProxy(proxyTestUpstream, &ProxyOptions{
+		RateLimit: &ProxyRateLimit{Costs: map[string]float64{"eth_getLogs": 2}},
+		Auth:      auth,
+	})
+
+	const ok = `{"jsonrpc":"2.0","result":"0x10","id":1}`
+	tests := []struct {
+		name   string
+		key    string
+		method string
+		ok     bool
+	}{
+		{"first", "a", "eth_blockNumber", true},
+		{"second", "a", "eth_blockNumber", true},
+		{"method cost", "a", "eth_getLogs", false},
+		{"third", "a", "eth_blockNumber", true},
+		{"exhausted", "a", "eth_blockNumber", false},
+		{"other key", "b", "eth_blockNumber", true},
+		{"no quota", "c", "eth_blockNumber", true},
+	}
+	for _, test := range tests {
+		body := `{"jsonrpc":"2.0","id":1,"method":"` + test.method + `"}`
+		rw := proxyTestDo(p, "POST", "/", body, http.Header{"X-Api-Key": {test.key}})
+		res := strings.TrimSpace(rw.Body.String())
+		switch {
+		case test.ok && res != ok:
+			t.Errorf("%s: got %s", test.name, res)
+		case !test.ok && (rw.Code != 429 || !strings.Contains(res, `"code":-32005,"message":"quota exceeded, retry in `) || rw.Header().Get("Retry-After") == ""):
+			t.Errorf("%s: got status %d, %s", test.name, rw.Code, res)
+		}
+	}
+	if p.limits.(*MemoryRateLimitStore).counters["quota:"+auth.Keys.Get("a").limitId()] == nil {
+		t.Errorf("quota counter not found by key id")
+	}
+}
+
+func TestProxyRateLimit(t *testing.T) {
+	auth := &ProxyAuth{Keys: NewAPIKeys(
+		&APIKey{Key: "a"},
+		&APIKey{Key: "b"},
+		&APIKey{Key: "fast", Rate: 100},
+	)}
+	p := NewProxy(proxyTestUpstream, &ProxyOptions{
+		RateLimit: &ProxyRateLimit{Rate: 0.01, Burst: 2, Costs: map[string]float64{"eth_getLogs": 2}, Key: RateLimitByAPIKey(auth)},
+		Auth:      auth,
+	})
+
+	const (
+		ok      = `{"jsonrpc":"2.0","result":"0x10","id":1}`
+		limited = `{"jsonrpc":"2.0","error":{"code":-32005,"message":"rate limit exceeded, retry in 100s","data":{"retryAfter":100}},"id":1}`
+	)
+	tests := []struct {
+		name   string
+		key    string
+		body   string
+		status int
+		expect string
+	}{
+		{"first", "a", `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}`, 200, ok},
+		{"second", "a", `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}`, 200, ok},
+		{"limited", "a", `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}`, 429, limited},
+		{"other key", "b", `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}`, 200, ok},
+		{"method cost", "b", `{"jsonrpc":"2.0","id":1,"method":"eth_getLogs"}`, 429, limited},
+		{"batch", "a", `[{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"},{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}]`, 200, "[" + limited + "," + limited + "]"},
+		{"key rate", "fast", `[{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"},{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"},{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}]`, 200, "[" + ok + "," + ok + "," + ok + "]"},
+	}
+	for _, test := range tests {
+		rw := proxyTestDo(p, "POST", "/", test.body, http.Header{"X-Api-Key": {test.key}})
+		if rw.Code != test.status {
+			t.Errorf("%s: got status %d, expected %d", test.name, rw.Code, test.status)
+		}
+		if res := strings.TrimSpace(rw.Body.String()); res != test.expect {
+			t.Errorf("%s: got %s, expected %s", test.name, res, test.expect)
+		}
+		if retry := rw.Header().Get("Retry-After"); (retry != "") != strings.Contains(test.expect, "-32005") {
+			t.Errorf("%s: Retry-After = %q", test.name, retry)
+		}
+	}
+}
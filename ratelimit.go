@@ -0,0 +1,136 @@
+package ethrpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit is a token bucket limiting the requests sent by a [RPC], for example to stay within
+// the compute units of a hosted provider. Each request consumes the cost of its method.
+type RateLimit struct {
+	Rate  float64            // tokens added per second
+	Burst float64            // maximum number of tokens, defaults to Rate
+	Costs map[string]float64 // cost of methods, others cost 1. For example {"eth_getLogs": 75, "eth_blockNumber": 10}
+
+	lk     sync.Mutex
+	bucket tokenBucket
+}
+
+// tokenBucket holds the state of a token bucket
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// take removes cost tokens from the bucket if enough are available, and otherwise returns how
+// long to wait until they are
+func (b *tokenBucket) take(now time.Time, cost, rate, burst float64) time.Duration {
+	if b.last.IsZero() {
+		b.tokens = burst
+	} else if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = min(burst, b.tokens+elapsed*rate)
+	}
+	b.last = now
+	// a request costing more than the burst can never be satisfied, so it empties the bucket
+	cost = min(cost, burst)
+	if b.tokens >= cost {
+		b.tokens -= cost
+		return 0
+	}
+	return time.Duration((cost - b.tokens) / rate * float64(time.Second))
+}
+
+// NewRateLimit returns a new [RateLimit] allowing rate tokens per second, with up to burst tokens
+// available at once
+func NewRateLimit(rate, burst float64) *RateLimit {
+	return &RateLimit{Rate: rate, Burst: burst}
+}
+
+// Cost returns the cost of a request for method
+func (l *RateLimit) Cost(method string) float64 {
+	if c, ok := l.Costs[method]; ok {
+		return c
+	}
+	return 1
+}
+
+func (l *RateLimit) burst() float64 {
+	if l.Burst <= 0 {
+		return l.Rate
+	}
+	return l.Burst
+}
+
+// Delay returns how long a request for method would currently wait
+func (l *RateLimit) Delay(method string) time.Duration {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	b := l.bucket
+	return b.take(time.Now(), l.Cost(method), l.Rate, l.burst())
+}
+
+// Wait waits until a request for method can be sent and consumes its cost. It returns
+// [ErrRateLimited] if ctx would expire before that.
+func (l *RateLimit) Wait(ctx context.Context, method string) error {
+	if l.Rate <= 0 {
+		return nil
+	}
+	for {
+		l.lk.Lock()
+		d := l.bucket.take(time.Now(), l.Cost(method), l.Rate, l.burst())
+		l.lk.Unlock()
+		if d == 0 {
+			return nil
+		}
+		if err := sleepCtx(ctx, d); err != nil {
+			return err
+		}
+	}
+}
+
+// sleepCtx waits for d, returning [ErrRateLimited] if ctx would expire first
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return ErrRateLimited
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either a number of seconds
+// or a http date
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
+
+// isRateLimited returns true if err means the server refused the request because of rate limits
+func isRateLimited(err error) bool {
+	var herr *HTTPError
+	var obj *ErrorObject
+	if errors.Is(err, ErrRateLimited) {
+		return true
+	}
+	if errors.As(err, &herr) && (herr.StatusCode == http.StatusTooManyRequests || herr.RetryAfter > 0) {
+		return true
+	}
+	return errors.As(err, &obj) && obj.Code == errLimitExceeded
+}
@@ -0,0 +1,197 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	tests := []struct {
+		name    string
+		elapsed time.Duration // since the previous take
+		cost    float64
+		expect  time.Duration
+	}{
+		{"full bucket", 0, 3, 0},
+		{"remaining tokens", 0, 2, 0},
+		{"empty bucket", 0, 1, 500 * time.Millisecond},
+		{"refilled", time.Second, 2, 0},
+		{"partial refill", 250 * time.Millisecond, 1, 250 * time.Millisecond},
+		{"capped at burst", time.Hour, 5, 0},
+		{"cost above burst", 0, 10, 2500 * time.Millisecond},
+	}
+	var b tokenBucket
+	now := start
+	for _, test := range tests {
+		now = now.Add(test.elapsed)
+		if d := b.take(now, test.cost, 2, 5); d != test.expect {
+			t.Errorf("%s: take = %s, expected %s", test.name, d, test.expect)
+		}
+	}
+}
+
+func TestRateLimitWait(t *testing.T) {
+	l := NewRateLimit(10, 2)
+	l.Costs = map[string]float64{"eth_getLogs": 2}
+
+	ctx := context.Background()
+	if err := l.Wait(ctx, "eth_getLogs"); err != nil {
+		t.Fatalf("first request waited: %s", err)
+	}
+	if d := l.Delay("eth_blockNumber"); d <= 0 || d > 100*time.Millisecond {
+		t.Errorf("Delay = %s", d)
+	}
+
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(short, "eth_getLogs"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("wait beyond the deadline: %v, expected ErrRateLimited", err)
+	}
+
+	begin := time.Now()
+	if err := l.Wait(ctx, "eth_blockNumber"); err != nil {
+		t.Errorf("Wait: %s", err)
+	}
+	if d := time.Since(begin); d < 50*time.Millisecond {
+		t.Errorf("Wait returned after %s", d)
+	}
+
+	if err := (&RateLimit{}).Wait(short, "eth_call"); err != nil {
+		t.Errorf("no rate: %s", err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{"", 0, 0},
+		{"3", 3 * time.Second, 3 * time.Second},
+		{"0", 0, 0},
+		{"-1", 0, 0},
+		{"soon", 0, 0},
+		{time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), 58 * time.Second, time.Minute},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0, 0},
+	}
+	for _, test := range tests {
+		if d := parseRetryAfter(test.value); d < test.min || d > test.max {
+			t.Errorf("parseRetryAfter(%q) = %s", test.value, d)
+		}
+	}
+}
+
+func TestIsRateLimited(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		expect bool
+	}{
+		{"nil", nil, false},
+		{"ErrRateLimited", fmt.Errorf("wait: %w", ErrRateLimited), true},
+		{"429", &HTTPError{StatusCode: 429}, true},
+		{"503 with retry after", &HTTPError{StatusCode: 503, RetryAfter: time.Second}, true},
+		{"502", &HTTPError{StatusCode: 502}, false},
+		{"limit exceeded", &ErrorObject{Code: errLimitExceeded}, true},
+		{"limit exceeded with status", fmt.Errorf("%w (%w)", &ErrorObject{Code: errLimitExceeded}, &HTTPError{StatusCode: 200}), true},
+		{"other json-rpc error", &ErrorObject{Code: errInvalidParams}, false},
+		{"other error", errors.New("connection refused"), false},
+	}
+	for _, test := range tests {
+		if res := isRateLimited(test.err); res != test.expect {
+			t.Errorf("%s: isRateLimited = %v", test.name, res)
+		}
+	}
+}
+
+// ratelimitTestServer answers with its name, or with the given http status and a json-rpc error
+// if status is not 200. hits counts the requests it received.
+func ratelimitTestServer(t *testing.T, name string, status int, hits *atomic.Int32) *RPC {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		var req Request
+		json.NewDecoder(r.Body).Decode(&req)
+		res := map[string]any{"jsonrpc": "2.0", "id": req.Id}
+		switch status {
+		case http.StatusOK:
+			res["result"] = name
+		case http.StatusTooManyRequests:
+			res["error"] = map[string]any{"code": errLimitExceeded, "message": "rate limited"}
+		default:
+			res["error"] = map[string]any{"code": errInternal, "message": "failed"}
+		}
+		rw.WriteHeader(status)
+		json.NewEncoder(rw).Encode(res)
+	}))
+	t.Cleanup(srv.Close)
+	return New(srv.URL)
+}
+
+func TestRPCListSend(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		setup    func(list RPCList)
+		timeout  time.Duration
+		expect   string // result, or message of the json-rpc error
+		err      error
+		hits     []int32
+	}{
+		{"empty", nil, nil, 0, "", ErrNoAvailableServer, nil},
+		{"first", []int{200, 200}, nil, 0, `"a"`, nil, []int32{1, 0}},
+		{"rate limited", []int{429, 200}, nil, 0, `"b"`, nil, []int32{1, 1}},
+		{"all rate limited", []int{429, 429}, nil, 0, "rate limited", nil, []int32{1, 1}},
+		{"other error", []int{500, 200}, nil, 0, "failed", nil, []int32{1, 0}},
+		{"throttled last", []int{200, 200}, func(list RPCList) { list[0].Throttle(time.Minute) }, 0, `"b"`, nil, []int32{0, 1}},
+		{"out of tokens last", []int{200, 200}, func(list RPCList) {
+			list[0].RateLimit = NewRateLimit(0.01, 1)
+			list[0].RateLimit.Wait(context.Background(), "eth_call")
+		}, 0, `"b"`, nil, []int32{0, 1}},
+		{"all throttled", []int{200, 200}, func(list RPCList) {
+			list[0].Throttle(time.Minute)
+			list[1].Throttle(time.Minute)
+		}, time.Second, "", ErrRateLimited, []int32{0, 0}},
+	}
+	for _, test := range tests {
+		hits := make([]atomic.Int32, len(test.statuses))
+		var list RPCList
+		for i, status := range test.statuses {
+			list = append(list, ratelimitTestServer(t, string(rune('a'+i)), status, &hits[i]))
+		}
+		if test.setup != nil {
+			test.setup(list)
+		}
+		ctx := context.Background()
+		if test.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, test.timeout)
+			defer cancel()
+		}
+
+		res, err := list.DoCtx(ctx, "eth_call")
+		out := string(res)
+		var obj *ErrorObject
+		if errors.As(err, &obj) {
+			out = obj.Message
+		} else if !errors.Is(err, test.err) {
+			t.Errorf("%s: got error %v, expected %v", test.name, err, test.err)
+		}
+		if out != test.expect {
+			t.Errorf("%s: got %s, expected %s", test.name, out, test.expect)
+		}
+		for i := range hits {
+			if n := hits[i].Load(); n != test.hits[i] {
+				t.Errorf("%s: server %d received %d requests, expected %d", test.name, i, n, test.hits[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// TxFees holds the fee fields of a transaction that are raised when replacing it.
+// Fields that do not apply to a given transaction type are left nil.
+type TxFees struct {
+	GasPrice             *big.Int // legacy & access list transactions
+	MaxFeePerGas         *big.Int // EIP-1559
+	MaxPriorityFeePerGas *big.Int // EIP-1559
+}
+
+// ReplacementPolicy describes how a stuck transaction should be replaced by a
+// new transaction using the same nonce and higher fees.
+type ReplacementPolicy struct {
+	Timeout      time.Duration // how long [TxTracker] waits for a transaction to be mined before replacing it
+	BumpPercent  int           // minimum fee increase, nodes require at least 10% (the default)
+	MaxFeePerGas *big.Int      // ceiling for GasPrice/MaxFeePerGas, nil means no limit
+}
+
+// DefaultReplacementPolicy matches the default replacement rules of geth's txpool.
+var DefaultReplacementPolicy = &ReplacementPolicy{
+	Timeout:     3 * time.Minute,
+	BumpPercent: 10,
+}
+
+// Bump returns the fees to use for a replacement transaction. Each fee is raised by
+// at least the minimum replacement percentage, and ErrFeeCeiling is returned if
+// doing so would exceed one of the configured ceilings.
+func (p *ReplacementPolicy) Bump(fees *TxFees) (*TxFees, error) {
+	if fees == nil {
+		return nil, ErrMissingFees
+	}
+	pct := p.BumpPercent
+	if pct <= 0 {
+		pct = 10
+	}
+
+	res := &TxFees{
+		GasPrice:             bumpFee(fees.GasPrice, pct),
+		MaxFeePerGas:         bumpFee(fees.MaxFeePerGas, pct),
+		MaxPriorityFeePerGas: bumpFee(fees.MaxPriorityFeePerGas, pct),
+	}
+
+	if res.MaxFeePerGas != nil && res.MaxPriorityFeePerGas != nil && res.MaxPriorityFeePerGas.Cmp(res.MaxFeePerGas) > 0 {
+		// tip cannot be higher than the fee cap
+		res.MaxFeePerGas = new(big.Int).Set(res.MaxPriorityFeePerGas)
+	}
+
+	if p.MaxFeePerGas != nil {
+		if res.GasPrice != nil && res.GasPrice.Cmp(p.MaxFeePerGas) > 0 {
+			return nil, ErrFeeCeiling
+		}
+		if res.MaxFeePerGas != nil && res.MaxFeePerGas.Cmp(p.MaxFeePerGas) > 0 {
+			return nil, ErrFeeCeiling
+		}
+	}
+	return res, nil
+}
+
+// bumpFee returns v increased by pct percent, rounded up, and always at least v+1
+func bumpFee(v *big.Int, pct int) *big.Int {
+	if v == nil {
+		return nil
+	}
+	res := new(big.Int).Mul(v, big.NewInt(int64(100+pct)))
+	res.Add(res, big.NewInt(99))
+	res.Div(res, big.NewInt(100))
+	if res.Cmp(v) <= 0 {
+		res.Add(v, big.NewInt(1))
+	}
+	return res
+}
+
+// TxSendFunc signs a version of a transaction using the given fees, sends it and returns its
+// hash. Versions that are already known to the node should not be reported as errors.
+type TxSendFunc func(ctx context.Context, fees *TxFees) (string, error)
+
+// TxTracker sends a transaction and waits for it to be mined, replacing it with a transaction
+// using the same nonce and higher fees each time it stays pending for longer than the policy's
+// Timeout. A TxTracker is used for a single transaction.
+type TxTracker struct {
+	Policy       *ReplacementPolicy // defaults to DefaultReplacementPolicy
+	PollInterval time.Duration      // how often receipts are checked, defaults to 3s
+
+	api  *Api
+	send TxSendFunc
+	fees *TxFees
+
+	lk     sync.Mutex
+	hashes []string
+}
+
+// NewTxTracker returns a [TxTracker] for a transaction with the given initial fees, each version
+// of which is signed and sent by send
+func (a *Api) NewTxTracker(fees *TxFees, send TxSendFunc) *TxTracker {
+	return &TxTracker{api: a, send: send, fees: fees}
+}
+
+// TrackTransaction returns a [TxTracker] for tx, which is signed with s. The fees of tx are set
+// to the ones of each version before it is signed.
+func (a *Api) TrackTransaction(s Signer, tx *Transaction) *TxTracker {
+	return a.NewTxTracker(tx.Fees(), func(ctx context.Context, fees *TxFees) (string, error) {
+		tx.SetFees(fees)
+		if err := tx.Sign(s); err != nil {
+			return "", err
+		}
+		raw, err := tx.MarshalBinary()
+		if err != nil {
+			return "", err
+		}
+		hash, err := tx.Hash()
+		if err != nil {
+			return "", err
+		}
+		if _, err := a.SendRawTransaction(ctx, raw); err != nil && !isAlreadyKnown(err) {
+			return "", err
+		}
+		return hash.Hex(), nil
+	})
+}
+
+// Hashes returns the hash of the transaction and of each of its replacements, in the order they
+// were sent
+func (t *TxTracker) Hashes() []string {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	return append([]string(nil), t.hashes...)
+}
+
+// Run sends the transaction and returns the receipt of whichever of its versions is mined. Once
+// the fee ceiling of the policy is reached, the last version is kept and Run keeps waiting.
+func (t *TxTracker) Run(ctx context.Context) (json.RawMessage, error) {
+	policy := t.Policy
+	if policy == nil {
+		policy = DefaultReplacementPolicy
+	}
+	interval := t.PollInterval
+	if interval <= 0 {
+		interval = 3 * time.Second
+	}
+
+	if err := t.sendFees(ctx, t.fees); err != nil {
+		return nil, err
+	}
+	sentAt := time.Now()
+	capped := false
+
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-tick.C:
+		}
+
+		for _, h := range t.Hashes() {
+			if r := t.receipt(ctx, h); r != nil {
+				return r, nil
+			}
+		}
+
+		if capped || policy.Timeout <= 0 || time.Since(sentAt) < policy.Timeout {
+			continue
+		}
+		fees, err := policy.Bump(t.fees)
+		if errors.Is(err, ErrFeeCeiling) {
+			capped = true
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		// if the replacement is refused, for example because a previous version was just mined,
+		// keep waiting for the versions already sent and try again after Timeout
+		if t.sendFees(ctx, fees) == nil {
+			t.fees = fees
+		}
+		sentAt = time.Now()
+	}
+}
+
+// sendFees sends the version of the transaction using fees and records its hash
+func (t *TxTracker) sendFees(ctx context.Context, fees *TxFees) error {
+	hash, err := t.send(ctx, fees)
+	if err != nil {
+		return err
+	}
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	t.hashes = append(t.hashes, hash)
+	return nil
+}
+
+// receipt returns the receipt of the transaction with the given hash, or nil if it has not been
+// mined yet
+func (t *TxTracker) receipt(ctx context.Context, hash string) json.RawMessage {
+	v, err := t.api.Handler.DoCtx(ctx, "eth_getTransactionReceipt", hash)
+	if err != nil || len(v) == 0 || string(v) == "null" {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,132 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReplacementPolicyBump(t *testing.T) {
+	n := big.NewInt
+	tests := []struct {
+		name   string
+		policy *ReplacementPolicy
+		fees   *TxFees
+		want   *TxFees
+		err    error
+	}{
+		{"legacy", &ReplacementPolicy{}, &TxFees{GasPrice: n(100)}, &TxFees{GasPrice: n(110)}, nil},
+		{"rounded up", &ReplacementPolicy{}, &TxFees{GasPrice: n(15)}, &TxFees{GasPrice: n(17)}, nil},
+		{"at least one", &ReplacementPolicy{BumpPercent: 1}, &TxFees{GasPrice: n(1)}, &TxFees{GasPrice: n(2)}, nil},
+		{"custom percent", &ReplacementPolicy{BumpPercent: 25}, &TxFees{GasPrice: n(100)}, &TxFees{GasPrice: n(125)}, nil},
+		{"eip-1559", &ReplacementPolicy{}, &TxFees{MaxFeePerGas: n(100), MaxPriorityFeePerGas: n(2)}, &TxFees{MaxFeePerGas: n(110), MaxPriorityFeePerGas: n(3)}, nil},
+		{"tip above cap", &ReplacementPolicy{}, &TxFees{MaxFeePerGas: n(10), MaxPriorityFeePerGas: n(12)}, &TxFees{MaxFeePerGas: n(14), MaxPriorityFeePerGas: n(14)}, nil},
+		{"at ceiling", &ReplacementPolicy{MaxFeePerGas: n(110)}, &TxFees{GasPrice: n(100)}, &TxFees{GasPrice: n(110)}, nil},
+		{"legacy over ceiling", &ReplacementPolicy{MaxFeePerGas: n(109)}, &TxFees{GasPrice: n(100)}, nil, ErrFeeCeiling},
+		{"fee cap over ceiling", &ReplacementPolicy{MaxFeePerGas: n(105)}, &TxFees{MaxFeePerGas: n(100), MaxPriorityFeePerGas: n(1)}, nil, ErrFeeCeiling},
+		{"clamped tip over ceiling", &ReplacementPolicy{MaxFeePerGas: n(12)}, &TxFees{MaxFeePerGas: n(10), MaxPriorityFeePerGas: n(12)}, nil, ErrFeeCeiling},
+		{"no fees", &ReplacementPolicy{}, nil, nil, ErrMissingFees},
+	}
+	for _, test := range tests {
+		got, err := test.policy.Bump(test.fees)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: got error %v, want %v", test.name, err, test.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if !feeEqual(got.GasPrice, test.want.GasPrice) || !feeEqual(got.MaxFeePerGas, test.want.MaxFeePerGas) || !feeEqual(got.MaxPriorityFeePerGas, test.want.MaxPriorityFeePerGas) {
+			t.Errorf("%s: got %v/%v/%v, want %v/%v/%v", test.name, got.GasPrice, got.MaxFeePerGas, got.MaxPriorityFeePerGas, test.want.GasPrice, test.want.MaxFeePerGas, test.want.MaxPriorityFeePerGas)
+		}
+	}
+}
+
+func feeEqual(a, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Cmp(b) == 0
+}
+
+// trackerTestNode returns a handler returning a receipt for the mined hash once it has been
+// polled after times
+func trackerTestNode(mined string, after int) Handler {
+	var lk sync.Mutex
+	polls := 0
+	return RequestHandlerFunc(func(ctx context.Context, req *Request) (json.RawMessage, error) {
+		if req.Method != "eth_getTransactionReceipt" {
+			return nil, &ErrorObject{Code: errMethodNotFound, Message: "unexpected method " + req.Method}
+		}
+		if req.Params.([]any)[0] != mined {
+			return json.RawMessage("null"), nil
+		}
+		lk.Lock()
+		defer lk.Unlock()
+		if polls++; polls <= after {
+			return json.RawMessage("null"), nil
+		}
+		return json.RawMessage(`{"transactionHash":"` + mined + `","status":"0x1"}`), nil
+	})
+}
+
+func TestTxTracker(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *ReplacementPolicy
+		mined  string
+		after  int     // polls before the receipt of mined is available
+		sends  []int64 // gas price of each version sent
+	}{
+		{"replaced", &ReplacementPolicy{Timeout: 20 * time.Millisecond}, "0x2", 0, []int64{100, 110}},
+		{"first version mined", &ReplacementPolicy{Timeout: time.Hour}, "0x1", 0, []int64{100}},
+		{"fee ceiling", &ReplacementPolicy{Timeout: time.Millisecond, MaxFeePerGas: big.NewInt(109)}, "0x1", 5, []int64{100}},
+	}
+	for _, test := range tests {
+		var lk sync.Mutex
+		var sent []int64
+		api := &Api{trackerTestNode(test.mined, test.after)}
+		tr := api.NewTxTracker(&TxFees{GasPrice: big.NewInt(100)}, func(ctx context.Context, fees *TxFees) (string, error) {
+			lk.Lock()
+			defer lk.Unlock()
+			sent = append(sent, fees.GasPrice.Int64())
+			return "0x" + strconv.Itoa(len(sent)), nil
+		})
+		tr.Policy = test.policy
+		tr.PollInterval = 5 * time.Millisecond
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		res, err := tr.Run(ctx)
+		cancel()
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		var receipt struct {
+			TransactionHash string `json:"transactionHash"`
+		}
+		if err := json.Unmarshal(res, &receipt); err != nil || receipt.TransactionHash != test.mined {
+			t.Errorf("%s: got receipt %s, want hash %s", test.name, res, test.mined)
+		}
+		lk.Lock()
+		if len(sent) != len(test.sends) {
+			t.Errorf("%s: sent %v, want %v", test.name, sent, test.sends)
+		} else {
+			for i := range sent {
+				if sent[i] != test.sends[i] {
+					t.Errorf("%s: sent %v, want %v", test.name, sent, test.sends)
+					break
+				}
+			}
+		}
+		lk.Unlock()
+		if h := tr.Hashes(); len(h) != len(test.sends) {
+			t.Errorf("%s: got hashes %v", test.name, h)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package ethrpc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ModChain/ethrpc/abi"
+)
+
+var (
+	revertErrorSelector = []byte{0x08, 0xc3, 0x79, 0xa0} // Error(string)
+	revertPanicSelector = []byte{0x4e, 0x48, 0x7b, 0x71} // Panic(uint256)
+
+	revertErrorArgs = abi.Arguments{{Type: abi.MustParseType("string")}}
+	revertPanicArgs = abi.Arguments{{Type: abi.MustParseType("uint256")}}
+)
+
+// RevertError is returned when a contract call reverts with data
+type RevertError struct {
+	Data   []byte     // raw revert data
+	Reason string     // message of Error(string) reverts, or panic code
+	Custom *abi.Error // matching custom error, if known
+	Args   []any      // arguments of the custom error
+	err    error
+}
+
+func (e *RevertError) Error() string {
+	switch {
+	case e.Custom != nil:
+		return fmt.Sprintf("execution reverted: %s%v", e.Custom.Name, e.Args)
+	case e.Reason != "":
+		return "execution reverted: " + e.Reason
+	default:
+		return "execution reverted: 0x" + hex.EncodeToString(e.Data)
+	}
+}
+
+func (e *RevertError) Unwrap() error {
+	return e.err
+}
+
+// revertData extracts the revert data from a json-rpc error, if any
+func revertData(err error) ([]byte, bool) {
+	var obj *ErrorObject
+	if !errors.As(err, &obj) {
+		return nil, false
+	}
+	s, ok := obj.Data.(string)
+	if !ok || !strings.HasPrefix(s, "0x") {
+		return nil, false
+	}
+	buf, e := hex.DecodeString(s[2:])
+	if e != nil {
+		return nil, false
+	}
+	return buf, true
+}
+
+// decodeRevert returns a [RevertError] if err contains revert data, using the errors in the
+// given abi (which can be nil) to decode custom errors. Otherwise err is returned as is.
+func decodeRevert(err error, a *abi.ABI) error {
+	data, ok := revertData(err)
+	if !ok {
+		return err
+	}
+	return newRevertError(data, a, err)
+}
+
+// newRevertError decodes the given revert data
+func newRevertError(data []byte, a *abi.ABI, err error) *RevertError {
+	res := &RevertError{Data: data, err: err}
+	if len(data) < 4 {
+		return res
+	}
+
+	switch {
+	case bytes.Equal(data[:4], revertErrorSelector):
+		if v, e := revertErrorArgs.Unpack(data[4:]); e == nil {
+			res.Reason = v[0].(string)
+		}
+	case bytes.Equal(data[:4], revertPanicSelector):
+		if v, e := revertPanicArgs.Unpack(data[4:]); e == nil {
+			res.Reason = fmt.Sprintf("panic 0x%x", v[0].(*big.Int))
+		}
+	case a != nil:
+		if custom := a.ErrorBySelector(data); custom != nil {
+			if args, e := custom.Unpack(data); e == nil {
+				res.Custom = custom
+				res.Args = args
+			}
+		}
+	}
+	return res
+}
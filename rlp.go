@@ -0,0 +1,62 @@
+package ethrpc
+
+import (
+	"encoding/binary"
+	"math/big"
+)
+
+// rlpBytes encodes a byte string using RLP
+func rlpBytes(b []byte) []byte {
+	if len(b) == 1 && b[0] < 0x80 {
+		return []byte{b[0]}
+	}
+	return append(rlpHeader(0x80, len(b)), b...)
+}
+
+// rlpUint encodes an integer using RLP
+func rlpUint(v uint64) []byte {
+	if v == 0 {
+		return []byte{0x80}
+	}
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	i := 0
+	for buf[i] == 0 {
+		i += 1
+	}
+	return rlpBytes(buf[i:])
+}
+
+// rlpBig encodes a big integer using RLP, nil is encoded as zero
+func rlpBig(v *big.Int) []byte {
+	if v == nil {
+		return []byte{0x80}
+	}
+	return rlpBytes(v.Bytes())
+}
+
+// rlpList encodes a list of already encoded items
+func rlpList(items ...[]byte) []byte {
+	n := 0
+	for _, item := range items {
+		n += len(item)
+	}
+	res := rlpHeader(0xc0, n)
+	for _, item := range items {
+		res = append(res, item...)
+	}
+	return res
+}
+
+func rlpHeader(base byte, ln int) []byte {
+	if ln < 56 {
+		return []byte{base + byte(ln)}
+	}
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(ln))
+	i := 0
+	for buf[i] == 0 {
+		i += 1
+	}
+	return append([]byte{base + 55 + byte(8-i)}, buf[i:]...)
+}
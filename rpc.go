@@ -8,8 +8,9 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
-	"log"
+	"log/slog"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/KarpelesLab/typutil"
@@ -26,10 +27,18 @@ type RPC struct {
 	lag        time.Duration // how long it takes for this endpoint to respond to eth_blockNumber
 	block      uint64        // latest block number
 	HTTPClient *http.Client
+	Logger     *slog.Logger // if set, requests are logged
+	LogOptions LogOptions
+	Tracer     Tracer     // if set, a span is opened for each request
+	RateLimit  *RateLimit // if set, limits the requests sent to the endpoint
 	// for RPC auth
 	username string
 	password string
 	override map[string]*typutil.Callable
+	mw       []Middleware
+	chain    RequestHandler
+	// time until which the endpoint asked not to receive requests, in unix nanoseconds
+	throttled atomic.Int64
 }
 
 // New returns a new instance of RPC to perform requests to the given RPC endpoint
@@ -83,10 +92,38 @@ func (r *RPC) DoNamedCtx(ctx context.Context, method string, args map[string]any
 	return r.SendCtx(ctx, NewRequest(method, args))
 }
 
+// SendCtx sends a raw request for processing, passing it through the middlewares set with Use
 func (r *RPC) SendCtx(ctx context.Context, req *Request) (json.RawMessage, error) {
-	// JSON RPC over http is simple
-	//log.Printf("[RPC] → %s %v", method, args)
+	if r.Tracer != nil {
+		return traceRequest(ctx, r.Tracer, EndpointLabel(r.host), req, RequestHandlerFunc(r.sendChain))
+	}
+	return r.sendChain(ctx, req)
+}
+
+func (r *RPC) sendChain(ctx context.Context, req *Request) (json.RawMessage, error) {
+	if r.chain != nil {
+		return r.chain.SendCtx(ctx, req)
+	}
+	return r.send(ctx, req)
+}
+
+// Use adds middlewares processing the requests sent through this RPC, including overridden
+// methods and requests passed to [RPC.Forward]. Middlewares added first are the outermost ones.
+// Use must not be called while requests are being performed.
+func (r *RPC) Use(mw ...Middleware) {
+	r.mw = append(r.mw, mw...)
+	r.chain = chainMiddleware(RequestHandlerFunc(r.send), r.mw)
+}
+
+func (r *RPC) send(ctx context.Context, req *Request) (json.RawMessage, error) {
+	start := time.Now()
+	res, err := r.roundTrip(ctx, req)
+	r.log(ctx, req, start, res, err)
+	return res, err
+}
 
+func (r *RPC) roundTrip(ctx context.Context, req *Request) (json.RawMessage, error) {
+	// JSON RPC over http is simple
 	if f, ok := r.override[req.Method]; ok {
 		if req.Params == nil {
 			req.Params = []any{}
@@ -106,6 +143,10 @@ func (r *RPC) SendCtx(ctx context.Context, req *Request) (json.RawMessage, error
 		return nil, fs.ErrNotExist
 	}
 
+	if err := r.wait(ctx, req.Method); err != nil {
+		return nil, fmt.Errorf("error while performing %s: %w", req.Method, err)
+	}
+
 	hreq, err := req.HTTPRequest(ctx, r.host)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate HTTP request for %s: %w", req.Method, err)
@@ -122,23 +163,82 @@ func (r *RPC) SendCtx(ctx context.Context, req *Request) (json.RawMessage, error
 	}
 	defer resp.Body.Close()
 
+	var retryAfter time.Duration
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		if retryAfter == 0 && resp.StatusCode == http.StatusTooManyRequests {
+			retryAfter = time.Second
+		}
+		r.Throttle(retryAfter)
+	}
+
 	// decode response
 	reader := json.NewDecoder(resp.Body)
 	var res *Response
 	err = reader.Decode(&res)
-	if err != nil {
+	if err != nil || res == nil {
+		if resp.StatusCode >= 400 {
+			return nil, fmt.Errorf("error while performing %s: %w", req.Method, &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, RetryAfter: retryAfter})
+		}
+		if err == nil {
+			err = ErrInvalidResponse
+		}
 		return nil, fmt.Errorf("failed to decode response to %s: %w", req.Method, err)
 	}
 	if res.Error != nil {
-		//log.Printf("[RPC] ← Error: %s", res.Error.Error())
+		if resp.StatusCode >= 400 {
+			// keep the status, such as 429 for rate limits
+			return nil, fmt.Errorf("RPC error during %s: %w (%w)", req.Method, res.Error, &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, RetryAfter: retryAfter})
+		}
 		return nil, fmt.Errorf("RPC error during %s: %w", req.Method, res.Error)
 	}
 
-	//log.Printf("[RPC] ← %s", res.Result)
-
 	return res.Result, nil
 }
 
+// wait waits until a request for method can be sent to the endpoint
+func (r *RPC) wait(ctx context.Context, method string) error {
+	if d := time.Until(r.ThrottledUntil()); d > 0 {
+		if err := sleepCtx(ctx, d); err != nil {
+			return err
+		}
+	}
+	if r.RateLimit != nil {
+		return r.RateLimit.Wait(ctx, method)
+	}
+	return nil
+}
+
+// Delay returns how long a request for method would currently wait before being sent, because
+// of the endpoint asking to retry later or of RateLimit
+func (r *RPC) Delay(method string) time.Duration {
+	d := max(time.Until(r.ThrottledUntil()), 0)
+	if r.RateLimit != nil {
+		d = max(d, r.RateLimit.Delay(method))
+	}
+	return d
+}
+
+// Throttle stops requests from being sent to the endpoint for d. It is called when the endpoint
+// responds with a Retry-After header.
+func (r *RPC) Throttle(d time.Duration) {
+	until := time.Now().Add(d).UnixNano()
+	for {
+		cur := r.throttled.Load()
+		if cur >= until || r.throttled.CompareAndSwap(cur, until) {
+			return
+		}
+	}
+}
+
+// ThrottledUntil returns the time until which requests to the endpoint are held back
+func (r *RPC) ThrottledUntil() time.Time {
+	if v := r.throttled.Load(); v != 0 {
+		return time.Unix(0, v)
+	}
+	return time.Time{}
+}
+
 // To performs the request and puts the result into target
 func (r *RPC) To(target any, method string, args ...any) error {
 	v, err := r.Do(method, args...)
@@ -153,8 +253,14 @@ type ForwardOptions struct {
 	Cache  time.Duration
 }
 
-// Forward will write the RPC response to the given [http.ResponseWriter].
+// Forward will write the RPC response to the given [http.ResponseWriter]. If middlewares were
+// added with [RPC.Use], the request goes through them and the response is built from the result
+// instead of being copied from the server.
 func (r *RPC) Forward(ctx context.Context, rw http.ResponseWriter, req *Request, opts *ForwardOptions) {
+	if r.chain != nil {
+		r.forwardChain(ctx, rw, req, opts)
+		return
+	}
 	if f, ok := r.override[req.Method]; ok {
 		// do not forward but run locally
 		rw.Header().Set("Content-Type", "application/json")
@@ -230,7 +336,9 @@ func (r *RPC) Forward(ctx context.Context, rw http.ResponseWriter, req *Request,
 		// special case: we need to format the response json
 		buf, err := io.ReadAll(resp.Body)
 		if err != nil {
-			log.Printf("[http] failed to read response: %s", err)
+			if r.Logger != nil {
+				r.Logger.ErrorContext(ctx, "failed to read forwarded response", "method", req.Method, "endpoint", redactURL(r.host), "error", redactError(err, r.host))
+			}
 			return
 		}
 		outbuf := &bytes.Buffer{}
@@ -246,3 +354,28 @@ func (r *RPC) Forward(ctx context.Context, rw http.ResponseWriter, req *Request,
 	rw.WriteHeader(resp.StatusCode)
 	io.Copy(rw, resp.Body)
 }
+
+// forwardChain writes the response to req obtained through the middlewares
+func (r *RPC) forwardChain(ctx context.Context, rw http.ResponseWriter, req *Request, opts *ForwardOptions) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+	if opts != nil && opts.Cache > 0 {
+		rw.Header().Set("Cache-Control", fmt.Sprintf("public; max-age=%d", opts.Cache/time.Second))
+		rw.Header().Set("Expires", time.Now().Add(opts.Cache).Format(time.RFC1123))
+	}
+	enc := json.NewEncoder(rw)
+	if opts != nil && opts.Pretty {
+		enc.SetIndent("", "    ")
+	}
+
+	res, err := r.chain.SendCtx(ctx, req)
+	if err != nil {
+		var obj *ErrorObject
+		if !errors.As(err, &obj) {
+			rw.WriteHeader(http.StatusInternalServerError)
+		}
+		enc.Encode(req.makeError(err))
+		return
+	}
+	enc.Encode(&Response{JsonRpc: "2.0", Result: res, Id: req.Id})
+}
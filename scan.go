@@ -0,0 +1,278 @@
+package ethrpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// LogScanner walks a block range in windows to retrieve logs matching a query, adapting the
+// window size to the limits of the server. It is created by [Api.ScanLogs] and used as:
+//
+//	s := api.ScanLogs(ctx, query, from, to)
+//	defer s.Close()
+//	for s.Next() {
+//		log := s.Log()
+//	}
+//	if err := s.Err(); err != nil {
+//		...
+//	}
+type LogScanner struct {
+	Window    uint64 // initial number of blocks per eth_getLogs request, defaults to 2000
+	MaxWindow uint64 // maximum number of blocks per request, defaults to 10 times Window
+	Parallel  int    // maximum number of windows fetched concurrently, logs are still returned in order
+
+	api     *Api
+	ctx     context.Context
+	cancel  func()
+	query   FilterQuery
+	from    uint64
+	to      uint64
+	started bool
+	pending chan *logWindow
+	fetches chan struct{} // limits the number of concurrent fetches to Parallel
+	batch   []*Log
+	cur     *Log
+	err     error
+
+	lk        sync.Mutex
+	window    uint64
+	limit     uint64 // smallest window size that was rejected recently
+	good      uint64 // largest window size that was accepted since then
+	successes int
+}
+
+type logWindow struct {
+	logs []*Log
+	err  error
+	done chan struct{}
+}
+
+// ScanLogs returns a [LogScanner] for the logs matching q between blocks from and to, inclusive.
+// The range of q is ignored. Fields of the scanner can be changed before the first call to Next.
+func (a *Api) ScanLogs(ctx context.Context, q *FilterQuery, from, to uint64) *LogScanner {
+	ctx, cancel := context.WithCancel(ctx)
+	s := &LogScanner{
+		Window:   2000,
+		Parallel: 1,
+		api:      a,
+		ctx:      ctx,
+		cancel:   cancel,
+		from:     from,
+		to:       to,
+	}
+	if q != nil {
+		s.query = *q
+	}
+	s.query.BlockHash = nil
+	return s
+}
+
+// Next advances to the next log, returning false when there are no more logs or an error
+// happened
+func (s *LogScanner) Next() bool {
+	if !s.started {
+		s.start()
+	}
+	for len(s.batch) == 0 {
+		if s.err != nil {
+			return false
+		}
+		w, ok := <-s.pending
+		if !ok {
+			return false
+		}
+		select {
+		case <-w.done:
+		case <-s.ctx.Done():
+			s.err = s.ctx.Err()
+			return false
+		}
+		if w.err != nil {
+			s.err = w.err
+			s.cancel()
+			return false
+		}
+		s.batch = w.logs
+	}
+	s.cur, s.batch = s.batch[0], s.batch[1:]
+	return true
+}
+
+// Log returns the current log
+func (s *LogScanner) Log() *Log {
+	return s.cur
+}
+
+// Err returns the error that stopped the scan, if any
+func (s *LogScanner) Err() error {
+	return s.err
+}
+
+// Close stops the scan and releases associated resources
+func (s *LogScanner) Close() {
+	s.cancel()
+}
+
+func (s *LogScanner) start() {
+	s.started = true
+	if s.Window == 0 {
+		s.Window = 2000
+	}
+	if s.MaxWindow == 0 {
+		s.MaxWindow = 10 * s.Window
+	}
+	if s.MaxWindow < s.Window {
+		s.MaxWindow = s.Window
+	}
+	if s.Parallel < 1 {
+		s.Parallel = 1
+	}
+	s.window = s.Window
+	s.pending = make(chan *logWindow, s.Parallel)
+	s.fetches = make(chan struct{}, s.Parallel)
+	go s.dispatch()
+}
+
+// dispatch creates windows in order and starts fetching them, at most Parallel at a time
+func (s *LogScanner) dispatch() {
+	defer close(s.pending)
+	if s.from > s.to {
+		return
+	}
+	cur := s.from
+	for {
+		s.lk.Lock()
+		end := cur + s.window - 1
+		s.lk.Unlock()
+		if end > s.to || end < cur {
+			end = s.to
+		}
+
+		w := &logWindow{done: make(chan struct{})}
+		select {
+		case s.pending <- w:
+		case <-s.ctx.Done():
+			return
+		}
+		select {
+		case s.fetches <- struct{}{}:
+		case <-s.ctx.Done():
+			w.err = s.ctx.Err()
+			close(w.done)
+			return
+		}
+		go func(from, to uint64) {
+			defer close(w.done)
+			defer func() { <-s.fetches }()
+			w.logs, w.err = s.fetch(from, to)
+		}(cur, end)
+
+		if end == s.to {
+			return
+		}
+		cur = end + 1
+	}
+}
+
+// fetch returns the logs between from and to, splitting the range if the server rejects it
+func (s *LogScanner) fetch(from, to uint64) ([]*Log, error) {
+	q := s.query
+	q.Range(from, to)
+	logs, err := s.api.GetLogs(s.ctx, &q)
+	if err == nil {
+		s.grow(to - from + 1)
+		return logs, nil
+	}
+	if !isLogLimitError(err) || from == to {
+		return nil, err
+	}
+
+	// fetch the range again in smaller windows
+	size := to - from + 1
+	s.shrink(size)
+	var res []*Log
+	for cur := from; cur <= to; {
+		s.lk.Lock()
+		end := cur + min(s.window, size/2) - 1
+		s.lk.Unlock()
+		if end > to || end < cur {
+			end = to
+		}
+		logs, err := s.fetch(cur, end)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, logs...)
+		if end == to {
+			break
+		}
+		cur = end + 1
+	}
+	return res, nil
+}
+
+// shrink is called when a window of the given size was rejected by the server
+func (s *LogScanner) shrink(size uint64) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	if s.limit == 0 || size < s.limit {
+		s.limit = size
+	}
+	if s.good >= s.limit {
+		s.good = 0
+	}
+	s.window = min(s.window, max(size/2, s.good, 1))
+	s.successes = 0
+}
+
+// grow is called after a window of the given size was successfully fetched, and increases the
+// window size towards the smallest rejected size
+func (s *LogScanner) grow(size uint64) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	s.good = max(s.good, size)
+	s.successes += 1
+	if s.successes%20 == 0 {
+		// logs density may have changed, try larger windows again
+		s.limit = 0
+	}
+	switch {
+	case s.limit == 0:
+		s.window = min(max(s.window, s.good)*2, s.MaxWindow)
+	case s.limit > s.good+1:
+		s.window = (s.good + s.limit) / 2
+	default:
+		s.window = s.good
+	}
+}
+
+// isLogLimitError returns true if the error means the server refused a eth_getLogs request
+// because the range or the result were too large. Rate limit errors, which some servers report
+// with the same code, are not.
+func isLogLimitError(err error) bool {
+	var obj *ErrorObject
+	if !errors.As(err, &obj) {
+		return false
+	}
+	msg := strings.ToLower(obj.Message)
+	if strings.Contains(msg, "rate limit") || strings.Contains(msg, "too many requests") {
+		return false
+	}
+	for _, s := range []string{
+		"query returned more than",
+		"block range",
+		"range too large",
+		"range is too large",
+		"too many blocks",
+		"response size",
+		"max results",
+		"query timeout",
+	} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
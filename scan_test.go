@@ -0,0 +1,164 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// scanTestNode is a handler answering eth_getLogs with one log per block, rejecting ranges larger
+// than maxRange and failing with fail if set
+type scanTestNode struct {
+	maxRange uint64
+	fail     error
+	delay    time.Duration
+
+	lk       sync.Mutex
+	ranges   [][2]uint64
+	active   int
+	parallel int // maximum number of concurrent requests
+}
+
+func (n *scanTestNode) SendCtx(ctx context.Context, req *Request) (json.RawMessage, error) {
+	q := req.Params.([]any)[0].(*FilterQuery)
+	from, _ := strconv.ParseUint(q.FromBlock, 0, 64)
+	to, _ := strconv.ParseUint(q.ToBlock, 0, 64)
+
+	n.lk.Lock()
+	n.ranges = append(n.ranges, [2]uint64{from, to})
+	n.active++
+	n.parallel = max(n.parallel, n.active)
+	n.lk.Unlock()
+	defer func() {
+		n.lk.Lock()
+		n.active--
+		n.lk.Unlock()
+	}()
+	time.Sleep(n.delay)
+
+	if n.fail != nil {
+		return nil, n.fail
+	}
+	if n.maxRange > 0 && to-from+1 > n.maxRange {
+		return nil, &ErrorObject{Code: -32005, Message: "block range is too large"}
+	}
+	logs := make([]*Log, 0, to-from+1)
+	for b := from; b <= to; b++ {
+		logs = append(logs, &Log{BlockNumber: b})
+	}
+	return json.Marshal(logs)
+}
+
+func (n *scanTestNode) DoCtx(ctx context.Context, method string, args ...any) (json.RawMessage, error) {
+	return n.SendCtx(ctx, NewRequest(method, args...))
+}
+
+func TestLogScanner(t *testing.T) {
+	tests := []struct {
+		name     string
+		node     *scanTestNode
+		window   uint64
+		parallel int
+		from, to uint64
+	}{
+		{"single window", &scanTestNode{}, 100, 1, 10, 50},
+		{"several windows", &scanTestNode{}, 10, 1, 0, 99},
+		{"rejected windows", &scanTestNode{maxRange: 7}, 50, 1, 1, 200},
+		{"parallel", &scanTestNode{delay: time.Millisecond}, 5, 3, 1, 100},
+		{"parallel rejected", &scanTestNode{maxRange: 4, delay: time.Millisecond}, 10, 2, 1, 100},
+		{"empty range", &scanTestNode{}, 10, 1, 5, 4},
+	}
+	for _, test := range tests {
+		api := &Api{test.node}
+		s := api.ScanLogs(context.Background(), nil, test.from, test.to)
+		s.Window = test.window
+		s.Parallel = test.parallel
+
+		next := test.from
+		for s.Next() {
+			if b := s.Log().BlockNumber; b != next {
+				t.Errorf("%s: got block %d, want %d", test.name, b, next)
+				break
+			}
+			next++
+		}
+		s.Close()
+		if err := s.Err(); err != nil {
+			t.Errorf("%s: %s", test.name, err)
+		}
+		if test.from <= test.to && next != test.to+1 {
+			t.Errorf("%s: stopped at block %d, want %d", test.name, next, test.to+1)
+		}
+		test.node.lk.Lock()
+		if test.node.parallel > test.parallel {
+			t.Errorf("%s: %d concurrent requests, want at most %d", test.name, test.node.parallel, test.parallel)
+		}
+		test.node.lk.Unlock()
+	}
+}
+
+func TestLogScannerGrowth(t *testing.T) {
+	node := &scanTestNode{}
+	s := (&Api{node}).ScanLogs(context.Background(), nil, 1, 10000)
+	s.Window = 10
+	for s.Next() {
+	}
+	s.Close()
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	node.lk.Lock()
+	defer node.lk.Unlock()
+	largest := uint64(0)
+	for _, r := range node.ranges {
+		largest = max(largest, r[1]-r[0]+1)
+	}
+	if largest != 100 {
+		t.Errorf("largest window is %d, want 100 (10 times Window)", largest)
+	}
+}
+
+func TestLogScannerRateLimited(t *testing.T) {
+	rateErr := &ErrorObject{Code: -32005, Message: "daily request limit exceeded, rate limited"}
+	node := &scanTestNode{fail: rateErr}
+	s := (&Api{node}).ScanLogs(context.Background(), nil, 1, 1000)
+	s.Window = 100
+	for s.Next() {
+	}
+	s.Close()
+	if !errors.Is(s.Err(), rateErr) {
+		t.Errorf("got error %v, want the rate limit error", s.Err())
+	}
+	node.lk.Lock()
+	defer node.lk.Unlock()
+	for _, r := range node.ranges {
+		if r[1]-r[0]+1 != 100 {
+			t.Errorf("rate limit error shrank the window to %d blocks", r[1]-r[0]+1)
+		}
+	}
+}
+
+func TestIsLogLimitError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&ErrorObject{Code: -32005, Message: "query returned more than 10000 results"}, true},
+		{&ErrorObject{Code: -32602, Message: "eth_getLogs block range is too large, max 2000"}, true},
+		{&ErrorObject{Code: -32000, Message: "Log response size exceeded."}, true},
+		{&ErrorObject{Code: -32005, Message: "limit exceeded"}, false},
+		{&ErrorObject{Code: -32005, Message: "rate limit exceeded"}, false},
+		{&ErrorObject{Code: 429, Message: "Too Many Requests: block range allowed"}, false},
+		{&ErrorObject{Code: -32000, Message: "execution reverted"}, false},
+		{errors.New("query returned more than 10000 results"), false},
+	}
+	for _, test := range tests {
+		if got := isLogLimitError(test.err); got != test.want {
+			t.Errorf("isLogLimitError(%v) = %v, want %v", test.err, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package ethrpc
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/ModChain/ethrpc/abi"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// Signer is an account able to sign hashes, such as transaction hashes
+type Signer interface {
+	// Address returns the address of the account
+	Address() abi.Address
+	// SignHash returns a 65 bytes signature of the given 32 bytes hash in the [R || S || V]
+	// format, with V being the recovery id (0 or 1)
+	SignHash(hash []byte) ([]byte, error)
+}
+
+// PrivateKeySigner is a [Signer] holding a secp256k1 private key in memory
+type PrivateKeySigner struct {
+	key  *secp256k1.PrivateKey
+	addr abi.Address
+}
+
+// NewPrivateKeySigner returns a signer for the given 32 bytes private key
+func NewPrivateKeySigner(key []byte) (*PrivateKeySigner, error) {
+	if len(key) != 32 {
+		return nil, ErrInvalidKey
+	}
+	priv := secp256k1.PrivKeyFromBytes(key)
+	if priv.Key.IsZero() {
+		return nil, ErrInvalidKey
+	}
+	return &PrivateKeySigner{key: priv, addr: pubkeyAddress(priv.PubKey())}, nil
+}
+
+// ParsePrivateKey returns a signer for the given hex-encoded private key
+func ParsePrivateKey(s string) (*PrivateKeySigner, error) {
+	key, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	return NewPrivateKeySigner(key)
+}
+
+// Address returns the address matching the private key
+func (s *PrivateKeySigner) Address() abi.Address {
+	return s.addr
+}
+
+// SignHash signs the given hash
+func (s *PrivateKeySigner) SignHash(hash []byte) ([]byte, error) {
+	if len(hash) != 32 {
+		return nil, ErrInvalidHashLength
+	}
+	// compact signatures are [27 + recid] || R || S
+	sig := ecdsa.SignCompact(s.key, hash, false)
+	return append(sig[1:], sig[0]-27), nil
+}
+
+// RecoverAddress returns the address of the account that produced the given 65 bytes signature
+// of hash. V can be either 0/1 or 27/28.
+func RecoverAddress(hash, sig []byte) (abi.Address, error) {
+	if len(hash) != 32 {
+		return abi.Address{}, ErrInvalidHashLength
+	}
+	if len(sig) != 65 {
+		return abi.Address{}, ErrInvalidSignature
+	}
+	v := sig[64]
+	if v >= 27 {
+		v -= 27
+	}
+	if v > 1 {
+		return abi.Address{}, ErrInvalidSignature
+	}
+	compact := append([]byte{27 + v}, sig[:64]...)
+	pub, _, err := ecdsa.RecoverCompact(compact, hash)
+	if err != nil {
+		return abi.Address{}, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	return pubkeyAddress(pub), nil
+}
+
+// pubkeyAddress computes the ethereum address of a public key
+func pubkeyAddress(pub *secp256k1.PublicKey) abi.Address {
+	var res abi.Address
+	copy(res[:], abi.Keccak256(pub.SerializeUncompressed()[1:])[12:])
+	return res
+}
@@ -0,0 +1,14 @@
+package siwe
+
+import "errors"
+
+var (
+	ErrInvalidMessage     = errors.New("invalid siwe message")
+	ErrExpired            = errors.New("siwe message has expired")
+	ErrNotYetValid        = errors.New("siwe message is not valid yet")
+	ErrDomainMismatch     = errors.New("siwe message domain does not match")
+	ErrNonceMismatch      = errors.New("siwe message nonce does not match")
+	ErrChainMismatch      = errors.New("siwe message chain id does not match the node")
+	ErrUnknownChain       = errors.New("siwe message chain id is not a known chain")
+	ErrIncompleteVerifier = errors.New("siwe verifier requires a domain and a nonce")
+)
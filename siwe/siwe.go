@@ -0,0 +1,306 @@
+// Package siwe implements Sign-In with Ethereum (EIP-4361) messages
+package siwe
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ModChain/ethrpc/abi"
+)
+
+const (
+	preambleSuffix = " wants you to sign in with your Ethereum account:"
+	nonceChars     = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+)
+
+// Message is a Sign-In with Ethereum message. Timestamps are kept as RFC 3339 strings so a
+// parsed message serializes back to the exact text that was signed.
+type Message struct {
+	Scheme         string // optional, such as "https"
+	Domain         string
+	Address        abi.Address
+	Statement      string // optional
+	URI            string
+	Version        string
+	ChainId        uint64
+	Nonce          string
+	IssuedAt       string
+	ExpirationTime string // optional
+	NotBefore      string // optional
+	RequestId      string // optional
+	Resources      []string
+}
+
+// NewMessage returns a message for the given parameters, with a random nonce and issued now
+func NewMessage(domain string, addr abi.Address, uri string, chainId uint64) *Message {
+	return &Message{
+		Domain:   domain,
+		Address:  addr,
+		URI:      uri,
+		Version:  "1",
+		ChainId:  chainId,
+		Nonce:    GenerateNonce(),
+		IssuedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// GenerateNonce returns a random alphanumeric nonce suitable for a message. It panics if the
+// system's random number generator fails.
+func GenerateNonce() string {
+	const limit = 256 - 256%len(nonceChars) // bytes above are rejected to avoid modulo bias
+	res := make([]byte, 0, 17)
+	buf := make([]byte, 32)
+	for len(res) < cap(res) {
+		if _, err := rand.Read(buf); err != nil {
+			panic("siwe: failed to generate nonce: " + err.Error())
+		}
+		for _, c := range buf {
+			if int(c) < limit && len(res) < cap(res) {
+				res = append(res, nonceChars[int(c)%len(nonceChars)])
+			}
+		}
+	}
+	return string(res)
+}
+
+// Parse parses a message, strictly following the EIP-4361 format
+func Parse(s string) (*Message, error) {
+	lines := strings.Split(s, "\n")
+	p := &parser{lines: lines}
+	m := &Message{}
+
+	preamble, ok := strings.CutSuffix(p.next(), preambleSuffix)
+	if !ok {
+		return nil, p.errorf("missing preamble")
+	}
+	if scheme, domain, ok := strings.Cut(preamble, "://"); ok {
+		m.Scheme, m.Domain = scheme, domain
+	} else {
+		m.Domain = preamble
+	}
+
+	addr, err := abi.ParseAddress(p.next())
+	if err != nil || addr.Hex() != p.cur() {
+		return nil, p.errorf("address must be a EIP-55 checksummed address")
+	}
+	m.Address = addr
+
+	if p.next() != "" {
+		return nil, p.errorf("expected empty line")
+	}
+	if stmt := p.next(); stmt != "" {
+		m.Statement = stmt
+		if p.next() != "" {
+			return nil, p.errorf("expected empty line")
+		}
+	}
+
+	m.URI = p.field("URI: ", true)
+	m.Version = p.field("Version: ", true)
+	chainId := p.field("Chain ID: ", true)
+	m.Nonce = p.field("Nonce: ", true)
+	m.IssuedAt = p.field("Issued At: ", true)
+	m.ExpirationTime = p.field("Expiration Time: ", false)
+	m.NotBefore = p.field("Not Before: ", false)
+	m.RequestId = p.field("Request ID: ", false)
+	if p.err != nil {
+		return nil, p.err
+	}
+	if m.ChainId, err = parseChainId(chainId); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidMessage, err)
+	}
+	if p.peek() == "Resources:" {
+		p.next()
+		for p.pos < len(p.lines) {
+			res, ok := strings.CutPrefix(p.next(), "- ")
+			if !ok {
+				return nil, p.errorf("invalid resource")
+			}
+			m.Resources = append(m.Resources, res)
+		}
+	}
+	if p.pos < len(p.lines) {
+		return nil, p.errorf("unexpected content")
+	}
+
+	if err := m.check(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func parseChainId(s string) (uint64, error) {
+	if s == "" || s[0] < '1' || s[0] > '9' {
+		return 0, fmt.Errorf("invalid chain id %q", s)
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+type parser struct {
+	lines []string
+	pos   int
+	err   error
+}
+
+func (p *parser) next() string {
+	if p.pos >= len(p.lines) {
+		p.pos += 1
+		return ""
+	}
+	p.pos += 1
+	return p.lines[p.pos-1]
+}
+
+func (p *parser) cur() string {
+	if p.pos == 0 || p.pos > len(p.lines) {
+		return ""
+	}
+	return p.lines[p.pos-1]
+}
+
+func (p *parser) peek() string {
+	if p.pos >= len(p.lines) {
+		return ""
+	}
+	return p.lines[p.pos]
+}
+
+// field reads a "Name: value" line
+func (p *parser) field(prefix string, required bool) string {
+	if p.err != nil {
+		return ""
+	}
+	if v, ok := strings.CutPrefix(p.peek(), prefix); ok {
+		p.pos += 1
+		return v
+	}
+	if required {
+		p.pos += 1
+		p.err = p.errorf("expected %q", strings.TrimSuffix(prefix, ": "))
+	}
+	return ""
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: line %d: %s", ErrInvalidMessage, p.pos, fmt.Sprintf(format, args...))
+}
+
+// check validates the values of the fields
+func (m *Message) check() error {
+	if m.Scheme != "" && !validScheme(m.Scheme) {
+		return fmt.Errorf("%w: invalid scheme %q", ErrInvalidMessage, m.Scheme)
+	}
+	if u, err := url.Parse("//" + m.Domain); m.Domain == "" || err != nil || u.Host == "" || u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("%w: invalid domain %q", ErrInvalidMessage, m.Domain)
+	}
+	if strings.ContainsAny(m.Statement, "\n") {
+		return fmt.Errorf("%w: statement cannot contain new lines", ErrInvalidMessage)
+	}
+	if !validURI(m.URI) {
+		return fmt.Errorf("%w: invalid uri %q", ErrInvalidMessage, m.URI)
+	}
+	if m.Version != "1" {
+		return fmt.Errorf("%w: unsupported version %q", ErrInvalidMessage, m.Version)
+	}
+	if m.ChainId == 0 {
+		return fmt.Errorf("%w: missing chain id", ErrInvalidMessage)
+	}
+	if len(m.Nonce) < 8 || strings.Trim(m.Nonce, nonceChars) != "" {
+		return fmt.Errorf("%w: nonce must be at least 8 alphanumeric characters", ErrInvalidMessage)
+	}
+	for name, ts := range map[string]string{"issued at": m.IssuedAt, "expiration time": m.ExpirationTime, "not before": m.NotBefore} {
+		if ts == "" && name != "issued at" {
+			continue
+		}
+		if _, err := time.Parse(time.RFC3339, ts); err != nil {
+			return fmt.Errorf("%w: invalid %s %q", ErrInvalidMessage, name, ts)
+		}
+	}
+	if strings.ContainsAny(m.RequestId, " \n") {
+		return fmt.Errorf("%w: invalid request id %q", ErrInvalidMessage, m.RequestId)
+	}
+	for _, res := range m.Resources {
+		if !validURI(res) {
+			return fmt.Errorf("%w: invalid resource %q", ErrInvalidMessage, res)
+		}
+	}
+	return nil
+}
+
+func validScheme(s string) bool {
+	for n, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case n > 0 && (c >= '0' && c <= '9' || c == '+' || c == '-' || c == '.'):
+		default:
+			return false
+		}
+	}
+	return s != ""
+}
+
+func validURI(s string) bool {
+	if strings.ContainsAny(s, " \n") {
+		return false
+	}
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && validScheme(u.Scheme)
+}
+
+// String returns the message in the EIP-4361 format, as it is signed
+func (m *Message) String() string {
+	var b strings.Builder
+	if m.Scheme != "" {
+		b.WriteString(m.Scheme + "://")
+	}
+	b.WriteString(m.Domain + preambleSuffix + "\n")
+	b.WriteString(m.Address.Hex() + "\n\n")
+	if m.Statement != "" {
+		b.WriteString(m.Statement + "\n")
+	}
+	b.WriteString("\nURI: " + m.URI)
+	b.WriteString("\nVersion: " + m.Version)
+	b.WriteString("\nChain ID: " + strconv.FormatUint(m.ChainId, 10))
+	b.WriteString("\nNonce: " + m.Nonce)
+	b.WriteString("\nIssued At: " + m.IssuedAt)
+	if m.ExpirationTime != "" {
+		b.WriteString("\nExpiration Time: " + m.ExpirationTime)
+	}
+	if m.NotBefore != "" {
+		b.WriteString("\nNot Before: " + m.NotBefore)
+	}
+	if m.RequestId != "" {
+		b.WriteString("\nRequest ID: " + m.RequestId)
+	}
+	if len(m.Resources) > 0 {
+		b.WriteString("\nResources:")
+		for _, res := range m.Resources {
+			b.WriteString("\n- " + res)
+		}
+	}
+	return b.String()
+}
+
+// Validate checks the fields of the message, and that it is valid at the given time
+func (m *Message) Validate(now time.Time) error {
+	if err := m.check(); err != nil {
+		return err
+	}
+	if m.ExpirationTime != "" {
+		t, _ := time.Parse(time.RFC3339, m.ExpirationTime)
+		if !now.Before(t) {
+			return ErrExpired
+		}
+	}
+	if m.NotBefore != "" {
+		t, _ := time.Parse(time.RFC3339, m.NotBefore)
+		if now.Before(t) {
+			return ErrNotYetValid
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package siwe
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const siweTestMessage = `service.invalid wants you to sign in with your Ethereum account:
+0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2
+
+I accept the ServiceOrg Terms of Service: https://service.invalid/tos
+
+URI: https://service.invalid/login
+Version: 1
+Chain ID: 1
+Nonce: 32891756
+Issued At: 2021-09-30T16:25:24Z
+Expiration Time: 2021-10-30T16:25:24Z
+Not Before: 2021-09-30T16:25:24Z
+Request ID: req-1
+Resources:
+- ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq/
+- https://example.com/my-web2-claim.json`
+
+func TestParse(t *testing.T) {
+	minimal := strings.Join([]string{
+		"https://service.invalid:8443 wants you to sign in with your Ethereum account:",
+		"0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2",
+		"",
+		"",
+		"URI: https://service.invalid/login",
+		"Version: 1",
+		"Chain ID: 137",
+		"Nonce: abcdefgh",
+		"Issued At: 2021-09-30T16:25:24.000Z",
+	}, "\n")
+	replace := func(old, new string) string { return strings.Replace(siweTestMessage, old, new, 1) }
+
+	tests := []struct {
+		name string
+		msg  string
+		ok   bool
+	}{
+		{"full", siweTestMessage, true},
+		{"minimal", minimal, true},
+		{"no statement", replace("I accept the ServiceOrg Terms of Service: https://service.invalid/tos\n", ""), true},
+		{"lowercase address", replace("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2", "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"), false},
+		{"missing preamble", replace(" wants you to sign in with your Ethereum account:", ""), false},
+		{"missing nonce", replace("Nonce: 32891756\n", ""), false},
+		{"short nonce", replace("Nonce: 32891756", "Nonce: 1234"), false},
+		{"version", replace("Version: 1", "Version: 2"), false},
+		{"chain id zero", replace("Chain ID: 1", "Chain ID: 0"), false},
+		{"chain id leading zero", replace("Chain ID: 1", "Chain ID: 01"), false},
+		{"invalid timestamp", replace("Issued At: 2021-09-30T16:25:24Z", "Issued At: yesterday"), false},
+		{"invalid uri", replace("URI: https://service.invalid/login", "URI: login"), false},
+		{"invalid resource", replace("- https://example.com/my-web2-claim.json", "- not a uri"), false},
+		{"fields out of order", replace("Version: 1\nChain ID: 1", "Chain ID: 1\nVersion: 1"), false},
+		{"trailing content", siweTestMessage + "\nextra", false},
+		{"invalid domain", replace("service.invalid wants", "service.invalid/path wants"), false},
+	}
+	for _, test := range tests {
+		m, err := Parse(test.msg)
+		if !test.ok {
+			if !errors.Is(err, ErrInvalidMessage) {
+				t.Errorf("%s: got error %v, expected ErrInvalidMessage", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+		if s := m.String(); s != test.msg {
+			t.Errorf("%s: message does not serialize back to the same text:\n%s", test.name, s)
+		}
+	}
+
+	m, err := Parse(siweTestMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Domain != "service.invalid" || m.ChainId != 1 || m.Nonce != "32891756" || m.RequestId != "req-1" || len(m.Resources) != 2 {
+		t.Errorf("unexpected fields %+v", m)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	m, err := Parse(siweTestMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		now time.Time
+		err error
+	}{
+		{time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC), nil},
+		{time.Date(2021, 9, 30, 16, 25, 24, 0, time.UTC), nil},
+		{time.Date(2021, 9, 30, 16, 25, 23, 0, time.UTC), ErrNotYetValid},
+		{time.Date(2021, 10, 30, 16, 25, 24, 0, time.UTC), ErrExpired},
+	}
+	for _, test := range tests {
+		if err := m.Validate(test.now); !errors.Is(err, test.err) {
+			t.Errorf("Validate(%s) = %v, expected %v", test.now, err, test.err)
+		}
+	}
+}
+
+func TestGenerateNonce(t *testing.T) {
+	seen := map[string]bool{}
+	for range 100 {
+		n := GenerateNonce()
+		if len(n) != 17 || strings.Trim(n, nonceChars) != "" || seen[n] {
+			t.Fatalf("invalid nonce %q", n)
+		}
+		seen[n] = true
+	}
+}
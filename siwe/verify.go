@@ -0,0 +1,66 @@
+package siwe
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ModChain/ethrpc"
+	"github.com/ModChain/ethrpc/chains"
+)
+
+// Verifier checks messages against the values expected by the server. Domain and Nonce are
+// required, as without them a message signed for another site or session would be accepted.
+type Verifier struct {
+	Domain string           // expected domain, such as "example.com"
+	Nonce  string           // nonce issued to the client for this session
+	Now    func() time.Time // defaults to time.Now
+}
+
+// Verify checks that msg is valid now for the given domain and nonce, and that sig is a valid
+// signature of it, see [Verifier.Verify]
+func Verify(ctx context.Context, api *ethrpc.Api, domain, nonce string, msg *Message, sig []byte) error {
+	return (&Verifier{Domain: domain, Nonce: nonce}).Verify(ctx, api, msg, sig)
+}
+
+// Verify checks the message fields and validity period, that its chain id is a known chain
+// matching the node api is connected to, and that sig is a signature of the message by its
+// address. Signatures of contract wallets are checked with ERC-1271 or ERC-6492.
+// [ErrIncompleteVerifier] is returned if Domain or Nonce is empty.
+func (v *Verifier) Verify(ctx context.Context, api *ethrpc.Api, msg *Message, sig []byte) error {
+	if v.Domain == "" || v.Nonce == "" {
+		return ErrIncompleteVerifier
+	}
+	now := time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+	if err := msg.Validate(now); err != nil {
+		return err
+	}
+	if msg.Domain != v.Domain {
+		return fmt.Errorf("%w: got %s", ErrDomainMismatch, msg.Domain)
+	}
+	if msg.Nonce != v.Nonce {
+		return ErrNonceMismatch
+	}
+	if chains.Get(msg.ChainId) == nil {
+		return fmt.Errorf("%w: %d", ErrUnknownChain, msg.ChainId)
+	}
+	chainId, err := api.ChainId(ctx)
+	if err != nil {
+		return err
+	}
+	if chainId != msg.ChainId {
+		return fmt.Errorf("%w: message is for chain %d, node is on chain %d", ErrChainMismatch, msg.ChainId, chainId)
+	}
+
+	ok, err := api.VerifyMessage(ctx, msg.Address, []byte(msg.String()), sig)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ethrpc.ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package siwe
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ModChain/ethrpc"
+)
+
+// verifyTestNode returns a node on the given chain where no address has code
+func verifyTestNode(chainId string) *ethrpc.Api {
+	return &ethrpc.Api{Handler: ethrpc.RequestHandlerFunc(func(ctx context.Context, req *ethrpc.Request) (json.RawMessage, error) {
+		switch req.Method {
+		case "eth_chainId":
+			return json.Marshal(chainId)
+		case "eth_getCode":
+			return json.RawMessage(`"0x"`), nil
+		}
+		return nil, &ethrpc.ErrorObject{Code: -32601, Message: "method not found"}
+	})}
+}
+
+func TestVerify(t *testing.T) {
+	key, err := ethrpc.ParsePrivateKey("0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ethrpc.ParsePrivateKey("0x0000000000000000000000000000000000000000000000000000000000000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	newMessage := func(chainId uint64) *Message {
+		m := NewMessage("example.com", key.Address(), "https://example.com/login", chainId)
+		m.Nonce = "abcdefgh1234"
+		m.IssuedAt = "2024-01-01T11:59:00Z"
+		m.ExpirationTime = "2024-01-01T12:10:00Z"
+		return m
+	}
+	sign := func(s ethrpc.Signer, m *Message) []byte {
+		sig, err := ethrpc.SignMessage(s, []byte(m.String()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sig
+	}
+	valid := newMessage(1)
+	verifier := &Verifier{Domain: "example.com", Nonce: "abcdefgh1234", Now: func() time.Time { return now }}
+
+	tests := []struct {
+		name     string
+		verifier *Verifier
+		api      *ethrpc.Api
+		msg      *Message
+		sig      []byte
+		err      error
+	}{
+		{"valid", verifier, verifyTestNode("0x1"), valid, sign(key, valid), nil},
+		{"other signer", verifier, verifyTestNode("0x1"), valid, sign(other, valid), ethrpc.ErrInvalidSignature},
+		{"no domain", &Verifier{Nonce: "abcdefgh1234", Now: verifier.Now}, verifyTestNode("0x1"), valid, sign(key, valid), ErrIncompleteVerifier},
+		{"no nonce", &Verifier{Domain: "example.com", Now: verifier.Now}, verifyTestNode("0x1"), valid, sign(key, valid), ErrIncompleteVerifier},
+		{"other domain", &Verifier{Domain: "evil.com", Nonce: "abcdefgh1234", Now: verifier.Now}, verifyTestNode("0x1"), valid, sign(key, valid), ErrDomainMismatch},
+		{"other nonce", &Verifier{Domain: "example.com", Nonce: "abcdefgh5678", Now: verifier.Now}, verifyTestNode("0x1"), valid, sign(key, valid), ErrNonceMismatch},
+		{"expired", &Verifier{Domain: "example.com", Nonce: "abcdefgh1234"}, verifyTestNode("0x1"), valid, sign(key, valid), ErrExpired},
+		{"other chain", verifier, verifyTestNode("0x89"), valid, sign(key, valid), ErrChainMismatch},
+		{"unknown chain", verifier, verifyTestNode("0x1"), newMessage(987654321987), sign(key, newMessage(987654321987)), ErrUnknownChain},
+	}
+	for _, test := range tests {
+		err := test.verifier.Verify(context.Background(), test.api, test.msg, test.sig)
+		if !errors.Is(err, test.err) || (test.err == nil && err != nil) {
+			t.Errorf("%s: got %v, expected %v", test.name, err, test.err)
+		}
+	}
+
+	if err := Verify(context.Background(), verifyTestNode("0x1"), "example.com", "abcdefgh1234", valid, sign(key, valid)); !errors.Is(err, ErrExpired) {
+		t.Errorf("Verify() = %v, expected ErrExpired", err)
+	}
+	if err := Verify(context.Background(), verifyTestNode("0x1"), "", "", valid, sign(key, valid)); !errors.Is(err, ErrIncompleteVerifier) {
+		t.Errorf("Verify() = %v, expected ErrIncompleteVerifier", err)
+	}
+}
@@ -0,0 +1,315 @@
+package ethrpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/ModChain/ethrpc/abi"
+)
+
+// TokenStandard identifies a token standard
+type TokenStandard int
+
+const (
+	ERC20 TokenStandard = iota
+	ERC721
+	ERC1155
+)
+
+func (s TokenStandard) String() string {
+	switch s {
+	case ERC721:
+		return "ERC-721"
+	case ERC1155:
+		return "ERC-1155"
+	default:
+		return "ERC-20"
+	}
+}
+
+var (
+	ERC20ABI = abi.MustParseHuman(
+		"function name() view returns (string)",
+		"function symbol() view returns (string)",
+		"function decimals() view returns (uint8)",
+		"function totalSupply() view returns (uint256)",
+		"function balanceOf(address owner) view returns (uint256)",
+		"function allowance(address owner, address spender) view returns (uint256)",
+		"function transfer(address to, uint256 value) returns (bool)",
+		"function approve(address spender, uint256 value) returns (bool)",
+		"function transferFrom(address from, address to, uint256 value) returns (bool)",
+		"event Transfer(address indexed from, address indexed to, uint256 value)",
+		"event Approval(address indexed owner, address indexed spender, uint256 value)",
+	)
+	ERC721ABI = abi.MustParseHuman(
+		"function name() view returns (string)",
+		"function symbol() view returns (string)",
+		"function tokenURI(uint256 tokenId) view returns (string)",
+		"function balanceOf(address owner) view returns (uint256)",
+		"function ownerOf(uint256 tokenId) view returns (address)",
+		"function getApproved(uint256 tokenId) view returns (address)",
+		"function isApprovedForAll(address owner, address operator) view returns (bool)",
+		"function approve(address to, uint256 tokenId)",
+		"function setApprovalForAll(address operator, bool approved)",
+		"function transferFrom(address from, address to, uint256 tokenId)",
+		"function safeTransferFrom(address from, address to, uint256 tokenId)",
+		"function safeTransferFrom(address from, address to, uint256 tokenId, bytes data)",
+		"event Transfer(address indexed from, address indexed to, uint256 indexed tokenId)",
+		"event Approval(address indexed owner, address indexed approved, uint256 indexed tokenId)",
+		"event ApprovalForAll(address indexed owner, address indexed operator, bool approved)",
+	)
+	ERC1155ABI = abi.MustParseHuman(
+		"function uri(uint256 id) view returns (string)",
+		"function balanceOf(address account, uint256 id) view returns (uint256)",
+		"function balanceOfBatch(address[] accounts, uint256[] ids) view returns (uint256[])",
+		"function isApprovedForAll(address account, address operator) view returns (bool)",
+		"function setApprovalForAll(address operator, bool approved)",
+		"function safeTransferFrom(address from, address to, uint256 id, uint256 value, bytes data)",
+		"function safeBatchTransferFrom(address from, address to, uint256[] ids, uint256[] values, bytes data)",
+		"event TransferSingle(address indexed operator, address indexed from, address indexed to, uint256 id, uint256 value)",
+		"event TransferBatch(address indexed operator, address indexed from, address indexed to, uint256[] ids, uint256[] values)",
+		"event ApprovalForAll(address indexed account, address indexed operator, bool approved)",
+		"event URI(string value, uint256 indexed id)",
+	)
+
+	erc20Transfer   = ERC20ABI.Event("Transfer")
+	erc20Approval   = ERC20ABI.Event("Approval")
+	erc721Transfer  = ERC721ABI.Event("Transfer")
+	erc721Approval  = ERC721ABI.Event("Approval")
+	erc1155Single   = ERC1155ABI.Event("TransferSingle")
+	erc1155Batch    = ERC1155ABI.Event("TransferBatch")
+	bytes32Metadata = abi.Arguments{{Type: abi.MustParseType("bytes32")}}
+)
+
+// TokenInfo holds the metadata of an ERC-20 or ERC-721 token
+type TokenInfo struct {
+	Address  abi.Address
+	Name     string
+	Symbol   string
+	Decimals uint8 // always zero for ERC-721
+}
+
+// TokenMetadata returns the name, symbol and decimals of the given token. Some tokens (such as
+// MKR) return bytes32 instead of strings, these are handled too. Values not implemented by the
+// token are left empty.
+func (a *Api) TokenMetadata(ctx context.Context, token abi.Address) (*TokenInfo, error) {
+	c := NewContract(token, ERC20ABI, a)
+	res := &TokenInfo{Address: token}
+
+	var err error
+	if res.Name, err = tokenString(ctx, c, "name"); err != nil {
+		return nil, err
+	}
+	if res.Symbol, err = tokenString(ctx, c, "symbol"); err != nil {
+		return nil, err
+	}
+	data, err := c.CallRaw(ctx, "decimals")
+	if err != nil {
+		if isRevert(err) {
+			return res, nil
+		}
+		return nil, err
+	}
+	if len(data) == 0 {
+		// no code or no such method
+		return res, nil
+	}
+	if err := c.ABI.Method("decimals").UnpackInto(&res.Decimals, data); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// tokenString calls a method returning either a string or a bytes32
+func tokenString(ctx context.Context, c *Contract, method string) (string, error) {
+	data, err := c.CallRaw(ctx, method)
+	if err != nil {
+		if isRevert(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	if len(data) == 0 {
+		return "", nil
+	}
+
+	var res string
+	if err := c.ABI.Method(method).UnpackInto(&res, data); err == nil {
+		return res, nil
+	}
+	var buf []byte
+	if err := bytes32Metadata.UnpackInto(&buf, data); err != nil {
+		return "", err
+	}
+	return string(bytes.TrimRight(buf, "\x00")), nil
+}
+
+// isRevert returns true if err means the call reverted, with or without revert data
+func isRevert(err error) bool {
+	var rev *RevertError
+	if errors.As(err, &rev) {
+		return true
+	}
+	var obj *ErrorObject
+	return errors.As(err, &obj) && strings.Contains(strings.ToLower(obj.Message), "revert")
+}
+
+// ERC20Balance returns the balance of owner for the given ERC-20 token
+func (a *Api) ERC20Balance(ctx context.Context, token, owner abi.Address) (*big.Int, error) {
+	var res *big.Int
+	err := NewContract(token, ERC20ABI, a).CallTo(ctx, &res, "balanceOf", owner)
+	return res, err
+}
+
+// ERC20Allowance returns the amount spender is allowed to transfer on behalf of owner
+func (a *Api) ERC20Allowance(ctx context.Context, token, owner, spender abi.Address) (*big.Int, error) {
+	var res *big.Int
+	err := NewContract(token, ERC20ABI, a).CallTo(ctx, &res, "allowance", owner, spender)
+	return res, err
+}
+
+// ERC721OwnerOf returns the owner of the given ERC-721 token
+func (a *Api) ERC721OwnerOf(ctx context.Context, token abi.Address, tokenId *big.Int) (abi.Address, error) {
+	var res abi.Address
+	err := NewContract(token, ERC721ABI, a).CallTo(ctx, &res, "ownerOf", tokenId)
+	return res, err
+}
+
+// ERC721TokenURI returns the metadata URI of the given ERC-721 token
+func (a *Api) ERC721TokenURI(ctx context.Context, token abi.Address, tokenId *big.Int) (string, error) {
+	var res string
+	err := NewContract(token, ERC721ABI, a).CallTo(ctx, &res, "tokenURI", tokenId)
+	return res, err
+}
+
+// ERC1155Balance returns the balance of account for the given ERC-1155 token id
+func (a *Api) ERC1155Balance(ctx context.Context, token, account abi.Address, id *big.Int) (*big.Int, error) {
+	var res *big.Int
+	err := NewContract(token, ERC1155ABI, a).CallTo(ctx, &res, "balanceOf", account, id)
+	return res, err
+}
+
+// ERC1155URI returns the metadata URI of the given ERC-1155 token id. The URI may contain the
+// {id} placeholder as specified by the standard.
+func (a *Api) ERC1155URI(ctx context.Context, token abi.Address, id *big.Int) (string, error) {
+	var res string
+	err := NewContract(token, ERC1155ABI, a).CallTo(ctx, &res, "uri", id)
+	return res, err
+}
+
+// TokenTransfer is a transfer decoded from a Transfer, TransferSingle or TransferBatch log
+type TokenTransfer struct {
+	Standard TokenStandard
+	Token    abi.Address
+	Operator abi.Address // ERC-1155 only
+	From     abi.Address
+	To       abi.Address
+	TokenId  *big.Int // ERC-721 and ERC-1155
+	Value    *big.Int // amount transferred, always 1 for ERC-721
+	Log      *Log
+}
+
+// DecodeTokenTransfers decodes an ERC-20, ERC-721 or ERC-1155 transfer log. Multiple transfers are
+// returned for ERC-1155 TransferBatch logs. ErrUnknownEvent is returned if the log is not a transfer.
+func DecodeTokenTransfers(l *Log) ([]*TokenTransfer, error) {
+	if len(l.Topics) == 0 {
+		return nil, ErrUnknownEvent
+	}
+	switch l.Topics[0] {
+	case erc20Transfer.ID():
+		// ERC-20 and ERC-721 share the same signature but not the same indexed params
+		res := &TokenTransfer{Token: l.Address, Log: l}
+		if len(l.Topics) == 4 {
+			res.Standard = ERC721
+			res.Value = big.NewInt(1)
+			values, err := erc721Transfer.DecodeLog(l.Topics, l.Data)
+			if err != nil {
+				return nil, err
+			}
+			if err := erc721Transfer.Inputs.CopyEach(values, &res.From, &res.To, &res.TokenId); err != nil {
+				return nil, err
+			}
+			return []*TokenTransfer{res}, nil
+		}
+		values, err := erc20Transfer.DecodeLog(l.Topics, l.Data)
+		if err != nil {
+			return nil, err
+		}
+		if err := erc20Transfer.Inputs.CopyEach(values, &res.From, &res.To, &res.Value); err != nil {
+			return nil, err
+		}
+		return []*TokenTransfer{res}, nil
+	case erc1155Single.ID():
+		values, err := erc1155Single.DecodeLog(l.Topics, l.Data)
+		if err != nil {
+			return nil, err
+		}
+		res := &TokenTransfer{Standard: ERC1155, Token: l.Address, Log: l}
+		if err := erc1155Single.Inputs.CopyEach(values, &res.Operator, &res.From, &res.To, &res.TokenId, &res.Value); err != nil {
+			return nil, err
+		}
+		return []*TokenTransfer{res}, nil
+	case erc1155Batch.ID():
+		values, err := erc1155Batch.DecodeLog(l.Topics, l.Data)
+		if err != nil {
+			return nil, err
+		}
+		var (
+			operator, from, to abi.Address
+			ids, amounts       []*big.Int
+		)
+		if err := erc1155Batch.Inputs.CopyEach(values, &operator, &from, &to, &ids, &amounts); err != nil {
+			return nil, err
+		}
+		if len(ids) != len(amounts) {
+			return nil, ErrInvalidResponse
+		}
+		res := make([]*TokenTransfer, len(ids))
+		for n := range ids {
+			res[n] = &TokenTransfer{Standard: ERC1155, Token: l.Address, Operator: operator, From: from, To: to, TokenId: ids[n], Value: amounts[n], Log: l}
+		}
+		return res, nil
+	}
+	return nil, ErrUnknownEvent
+}
+
+// TokenApproval is an approval decoded from an ERC-20 or ERC-721 Approval log
+type TokenApproval struct {
+	Standard TokenStandard
+	Token    abi.Address
+	Owner    abi.Address
+	Spender  abi.Address
+	Value    *big.Int // ERC-20 only
+	TokenId  *big.Int // ERC-721 only
+	Log      *Log
+}
+
+// DecodeTokenApproval decodes an ERC-20 or ERC-721 Approval log
+func DecodeTokenApproval(l *Log) (*TokenApproval, error) {
+	if len(l.Topics) == 0 || l.Topics[0] != erc20Approval.ID() {
+		return nil, ErrUnknownEvent
+	}
+	res := &TokenApproval{Token: l.Address, Log: l}
+	if len(l.Topics) == 4 {
+		res.Standard = ERC721
+		values, err := erc721Approval.DecodeLog(l.Topics, l.Data)
+		if err != nil {
+			return nil, err
+		}
+		if err := erc721Approval.Inputs.CopyEach(values, &res.Owner, &res.Spender, &res.TokenId); err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+	values, err := erc20Approval.DecodeLog(l.Topics, l.Data)
+	if err != nil {
+		return nil, err
+	}
+	if err := erc20Approval.Inputs.CopyEach(values, &res.Owner, &res.Spender, &res.Value); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,236 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ModChain/ethrpc/abi"
+)
+
+var (
+	tokenTestAddress = abi.MustParseAddress("0x00000000000000000000000000000000000070ce")
+	tokenTestAlice   = abi.MustParseAddress("0x000000000000000000000000000000000000a11c")
+	tokenTestBob     = abi.MustParseAddress("0x0000000000000000000000000000000000000b0b")
+)
+
+// tokenTestNode returns a handler answering eth_call for the ERC-20 metadata methods. Values are
+// either the raw return data or an error, missing methods return no data.
+func tokenTestNode(results map[string]any) RequestHandlerFunc {
+	return func(ctx context.Context, req *Request) (json.RawMessage, error) {
+		msg := req.Params.([]any)[0].(*CallMsg)
+		for _, name := range []string{"name", "symbol", "decimals"} {
+			if string(ERC20ABI.Method(name).Selector()) != string(msg.Data) {
+				continue
+			}
+			switch v := results[name].(type) {
+			case error:
+				return nil, v
+			case []byte:
+				return json.Marshal("0x" + hex.EncodeToString(v))
+			}
+		}
+		return json.RawMessage(`"0x"`), nil
+	}
+}
+
+func tokenTestPack(t *testing.T, typ string, value any) []byte {
+	buf, err := abi.Arguments{{Type: abi.MustParseType(typ)}}.Pack(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf
+}
+
+func tokenTestTopic(v []byte) abi.Hash {
+	var res abi.Hash
+	copy(res[:], leftPad32(v))
+	return res
+}
+
+func TestTokenMetadata(t *testing.T) {
+	revert := &ErrorObject{Code: 3, Message: "execution reverted"}
+	mkr := [32]byte{'M', 'a', 'k', 'e', 'r'}
+	tests := []struct {
+		name    string
+		results map[string]any
+		expect  TokenInfo
+		err     error
+	}{
+		{
+			"strings",
+			map[string]any{"name": tokenTestPack(t, "string", "Test Token"), "symbol": tokenTestPack(t, "string", "TST"), "decimals": tokenTestPack(t, "uint8", uint8(18))},
+			TokenInfo{Name: "Test Token", Symbol: "TST", Decimals: 18},
+			nil,
+		},
+		{
+			"bytes32",
+			map[string]any{"name": tokenTestPack(t, "bytes32", mkr), "symbol": tokenTestPack(t, "bytes32", [32]byte{'M', 'K', 'R'}), "decimals": tokenTestPack(t, "uint8", uint8(18))},
+			TokenInfo{Name: "Maker", Symbol: "MKR", Decimals: 18},
+			nil,
+		},
+		{
+			"no decimals",
+			map[string]any{"name": tokenTestPack(t, "string", "NFT"), "symbol": tokenTestPack(t, "string", "N")},
+			TokenInfo{Name: "NFT", Symbol: "N"},
+			nil,
+		},
+		{
+			"reverts",
+			map[string]any{"name": revert, "symbol": revert, "decimals": revert},
+			TokenInfo{},
+			nil,
+		},
+		{
+			"no code",
+			nil,
+			TokenInfo{},
+			nil,
+		},
+		{
+			"server error",
+			map[string]any{"decimals": ErrInvalidResponse},
+			TokenInfo{},
+			ErrInvalidResponse,
+		},
+	}
+	for _, test := range tests {
+		api := &Api{tokenTestNode(test.results)}
+		res, err := api.TokenMetadata(context.Background(), tokenTestAddress)
+		if test.err != nil {
+			if !errors.Is(err, test.err) || res != nil {
+				t.Errorf("%s: got %v, %v, expected error %s", test.name, res, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+		test.expect.Address = tokenTestAddress
+		if *res != test.expect {
+			t.Errorf("%s: got %+v, expected %+v", test.name, res, test.expect)
+		}
+	}
+}
+
+func TestDecodeTokenTransfers(t *testing.T) {
+	transfer := erc20Transfer.ID()
+	alice, bob := tokenTestTopic(tokenTestAlice[:]), tokenTestTopic(tokenTestBob[:])
+	batch, err := erc1155Batch.Inputs.NonIndexed().Pack([]*big.Int{big.NewInt(1), big.NewInt(2)}, []*big.Int{big.NewInt(10), big.NewInt(20)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		log    *Log
+		expect []string // standard from to id value
+		err    error
+	}{
+		{
+			"erc20",
+			&Log{Topics: []abi.Hash{transfer, alice, bob}, Data: leftPad32([]byte{100})},
+			[]string{"ERC-20 " + tokenTestAlice.String() + " " + tokenTestBob.String() + " <nil> 100"},
+			nil,
+		},
+		{
+			"erc721",
+			&Log{Topics: []abi.Hash{transfer, alice, bob, tokenTestTopic([]byte{7})}},
+			[]string{"ERC-721 " + tokenTestAlice.String() + " " + tokenTestBob.String() + " 7 1"},
+			nil,
+		},
+		{
+			"erc1155 single",
+			&Log{Topics: []abi.Hash{erc1155Single.ID(), bob, alice, bob}, Data: append(leftPad32([]byte{3}), leftPad32([]byte{4})...)},
+			[]string{"ERC-1155 " + tokenTestAlice.String() + " " + tokenTestBob.String() + " 3 4"},
+			nil,
+		},
+		{
+			"erc1155 batch",
+			&Log{Topics: []abi.Hash{erc1155Batch.ID(), bob, alice, bob}, Data: batch},
+			[]string{
+				"ERC-1155 " + tokenTestAlice.String() + " " + tokenTestBob.String() + " 1 10",
+				"ERC-1155 " + tokenTestAlice.String() + " " + tokenTestBob.String() + " 2 20",
+			},
+			nil,
+		},
+		{"no topics", &Log{}, nil, ErrUnknownEvent},
+		{"approval", &Log{Topics: []abi.Hash{erc20Approval.ID(), alice, bob}, Data: leftPad32([]byte{1})}, nil, ErrUnknownEvent},
+		{"missing data", &Log{Topics: []abi.Hash{transfer, alice, bob}}, nil, abi.ErrInvalidData},
+	}
+	for _, test := range tests {
+		res, err := DecodeTokenTransfers(test.log)
+		if test.err != nil {
+			if !errors.Is(err, test.err) || res != nil {
+				t.Errorf("%s: got %v, %v, expected error %s", test.name, res, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+		if len(res) != len(test.expect) {
+			t.Errorf("%s: got %d transfers, expected %d", test.name, len(res), len(test.expect))
+			continue
+		}
+		for n, tr := range res {
+			s := tr.Standard.String() + " " + tr.From.String() + " " + tr.To.String() + " " + tr.TokenId.String() + " " + tr.Value.String()
+			if s != test.expect[n] {
+				t.Errorf("%s: transfer %d is %s, expected %s", test.name, n, s, test.expect[n])
+			}
+			if tr.Log != test.log {
+				t.Errorf("%s: transfer %d does not reference its log", test.name, n)
+			}
+		}
+	}
+}
+
+func TestDecodeTokenApproval(t *testing.T) {
+	approval := erc20Approval.ID()
+	alice, bob := tokenTestTopic(tokenTestAlice[:]), tokenTestTopic(tokenTestBob[:])
+
+	tests := []struct {
+		name   string
+		log    *Log
+		expect string // standard owner spender id value
+		err    error
+	}{
+		{
+			"erc20",
+			&Log{Topics: []abi.Hash{approval, alice, bob}, Data: leftPad32([]byte{50})},
+			"ERC-20 " + tokenTestAlice.String() + " " + tokenTestBob.String() + " <nil> 50",
+			nil,
+		},
+		{
+			"erc721",
+			&Log{Topics: []abi.Hash{approval, alice, bob, tokenTestTopic([]byte{9})}},
+			"ERC-721 " + tokenTestAlice.String() + " " + tokenTestBob.String() + " 9 <nil>",
+			nil,
+		},
+		{"transfer", &Log{Topics: []abi.Hash{erc20Transfer.ID(), alice, bob}, Data: leftPad32([]byte{1})}, "", ErrUnknownEvent},
+		{"missing data", &Log{Topics: []abi.Hash{approval, alice, bob}}, "", abi.ErrInvalidData},
+		{"too many topics", &Log{Topics: []abi.Hash{approval, alice, bob, alice, bob}}, "", abi.ErrInvalidData},
+	}
+	for _, test := range tests {
+		res, err := DecodeTokenApproval(test.log)
+		if test.err != nil {
+			if !errors.Is(err, test.err) || res != nil {
+				t.Errorf("%s: got %v, %v, expected error %s", test.name, res, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+		s := res.Standard.String() + " " + res.Owner.String() + " " + res.Spender.String() + " " + res.TokenId.String() + " " + res.Value.String()
+		if s != test.expect {
+			t.Errorf("%s: got %s, expected %s", test.name, s, test.expect)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Tracer starts spans around requests. Its shape follows the tracer API of OpenTelemetry, and
+// the ethrpcotel package provides an adapter for it.
+type Tracer interface {
+	// Start starts a span as a child of the span in ctx, if any, and returns a context holding it
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a span started by a [Tracer]
+type Span interface {
+	SetAttribute(key string, value any)
+	RecordError(err error) // records err and marks the span as failed
+	End()
+	SpanContext() SpanContext // identifies the span in propagated headers, can be invalid
+}
+
+// SpanContext identifies a span across processes, as carried by W3C traceparent headers
+type SpanContext struct {
+	TraceId    [16]byte
+	SpanId     [8]byte
+	Flags      byte   // 1 if the trace is sampled
+	TraceState string // value of the tracestate header, if any
+	Remote     bool   // true if the span context was received from another process
+}
+
+type spanContextKey struct{}
+
+// IsValid returns true if both the trace and span ids are set
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceId != [16]byte{} && sc.SpanId != [8]byte{}
+}
+
+// TraceParent returns the value of the traceparent header for sc
+func (sc SpanContext) TraceParent() string {
+	return fmt.Sprintf("00-%x-%x-%02x", sc.TraceId[:], sc.SpanId[:], sc.Flags)
+}
+
+// ParseTraceParent parses the value of a traceparent header
+func ParseTraceParent(s string) (SpanContext, error) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return sc, ErrInvalidTraceParent
+	}
+	var version, flags [1]byte
+	if !decodeTraceField(version[:], parts[0]) || !decodeTraceField(sc.TraceId[:], parts[1]) || !decodeTraceField(sc.SpanId[:], parts[2]) || !decodeTraceField(flags[:], parts[3]) {
+		return sc, ErrInvalidTraceParent
+	}
+	sc.Flags = flags[0]
+	if !sc.IsValid() {
+		return sc, ErrInvalidTraceParent
+	}
+	return sc, nil
+}
+
+// decodeTraceField decodes a lowercase hex field of exactly len(dst) bytes
+func decodeTraceField(dst []byte, s string) bool {
+	if len(s) != len(dst)*2 || strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
+
+// ContextWithSpanContext returns a context carrying sc, which is sent in the traceparent header
+// of requests made with it
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context carried by ctx, if any
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok && sc.IsValid()
+}
+
+// InjectTraceParent sets the traceparent and tracestate headers from the span context in ctx
+func InjectTraceParent(ctx context.Context, h http.Header) {
+	sc, ok := SpanContextFromContext(ctx)
+	if !ok {
+		return
+	}
+	h.Set("traceparent", sc.TraceParent())
+	if sc.TraceState != "" {
+		h.Set("tracestate", sc.TraceState)
+	}
+}
+
+// ExtractTraceParent returns a context carrying the remote span context found in the traceparent
+// and tracestate headers. ctx is returned unchanged if the headers are missing or invalid.
+func ExtractTraceParent(ctx context.Context, h http.Header) context.Context {
+	sc, err := ParseTraceParent(h.Get("traceparent"))
+	if err != nil {
+		return ctx
+	}
+	sc.TraceState = strings.Join(h.Values("tracestate"), ",")
+	sc.Remote = true
+	return ContextWithSpanContext(ctx, sc)
+}
+
+// TracingMiddleware returns a [Middleware] opening a span for each request, with the given
+// endpoint label recorded as server.address. See [RPC.Tracer] to trace the requests of a RPC.
+func TracingMiddleware(t Tracer, endpoint string) Middleware {
+	return func(next RequestHandler) RequestHandler {
+		return RequestHandlerFunc(func(ctx context.Context, req *Request) (json.RawMessage, error) {
+			return traceRequest(ctx, t, endpoint, req, next)
+		})
+	}
+}
+
+// traceRequest sends req to next within a new span
+func traceRequest(ctx context.Context, t Tracer, endpoint string, req *Request, next RequestHandler) (json.RawMessage, error) {
+	ctx, span := t.Start(ctx, req.Method)
+	defer span.End()
+	if sc := span.SpanContext(); sc.IsValid() {
+		ctx = ContextWithSpanContext(ctx, sc)
+	}
+
+	span.SetAttribute("rpc.system", "jsonrpc")
+	span.SetAttribute("rpc.method", req.Method)
+	span.SetAttribute("rpc.jsonrpc.version", "2.0")
+	span.SetAttribute("server.address", endpoint)
+	if req.Id != nil {
+		span.SetAttribute("rpc.jsonrpc.request_id", fmt.Sprint(req.Id))
+	}
+
+	res, err := next.SendCtx(ctx, req)
+	if err != nil {
+		var obj *ErrorObject
+		var herr *HTTPError
+		if errors.As(err, &obj) {
+			span.SetAttribute("rpc.jsonrpc.error_code", obj.Code)
+			span.SetAttribute("rpc.jsonrpc.error_message", obj.Message)
+		}
+		if errors.As(err, &herr) {
+			span.SetAttribute("http.response.status_code", herr.StatusCode)
+		}
+		span.RecordError(err)
+	}
+	return res, err
+}
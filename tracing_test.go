@@ -0,0 +1,156 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	const (
+		traceId = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanId  = "00f067aa0ba902b7"
+	)
+	tests := []struct {
+		name  string
+		value string
+		ok    bool
+		flags byte
+	}{
+		{"sampled", "00-" + traceId + "-" + spanId + "-01", true, 1},
+		{"not sampled", "00-" + traceId + "-" + spanId + "-00", true, 0},
+		{"spaces", " 00-" + traceId + "-" + spanId + "-01 ", true, 1},
+		{"future version", "cc-" + traceId + "-" + spanId + "-01-extra", true, 1},
+		{"extra field", "00-" + traceId + "-" + spanId + "-01-extra", false, 0},
+		{"version ff", "ff-" + traceId + "-" + spanId + "-01", false, 0},
+		{"uppercase trace id", "00-4BF92F3577B34DA6A3CE929D0E0E4736-" + spanId + "-01", false, 0},
+		{"uppercase version", "0A-" + traceId + "-" + spanId + "-01", false, 0},
+		{"zero trace id", "00-00000000000000000000000000000000-" + spanId + "-01", false, 0},
+		{"zero span id", "00-" + traceId + "-0000000000000000-01", false, 0},
+		{"short trace id", "00-" + traceId[2:] + "-" + spanId + "-01", false, 0},
+		{"invalid hex", "00-" + traceId + "-" + spanId + "-0g", false, 0},
+		{"missing flags", "00-" + traceId + "-" + spanId, false, 0},
+		{"empty", "", false, 0},
+	}
+	for _, test := range tests {
+		sc, err := ParseTraceParent(test.value)
+		if !test.ok {
+			if !errors.Is(err, ErrInvalidTraceParent) {
+				t.Errorf("%s: got %v, %v, expected ErrInvalidTraceParent", test.name, sc, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+		if fmt.Sprintf("%x", sc.TraceId) != traceId || fmt.Sprintf("%x", sc.SpanId) != spanId || sc.Flags != test.flags {
+			t.Errorf("%s: got %+v", test.name, sc)
+		}
+		// round trip, future versions are written as version 00
+		if res := sc.TraceParent(); res != fmt.Sprintf("00-%s-%s-%02x", traceId, spanId, test.flags) {
+			t.Errorf("%s: TraceParent() = %s", test.name, res)
+		}
+	}
+}
+
+func TestTraceParentHeaders(t *testing.T) {
+	tp := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tests := []struct {
+		name   string
+		header http.Header
+		ok     bool
+		state  string
+	}{
+		{"traceparent", http.Header{"Traceparent": {tp}}, true, ""},
+		{"tracestate", http.Header{"Traceparent": {tp}, "Tracestate": {"a=1", "b=2"}}, true, "a=1,b=2"},
+		{"invalid", http.Header{"Traceparent": {"00-00000000000000000000000000000000-00f067aa0ba902b7-01"}, "Tracestate": {"a=1"}}, false, ""},
+		{"missing", http.Header{}, false, ""},
+	}
+	for _, test := range tests {
+		ctx := ExtractTraceParent(context.Background(), test.header)
+		sc, ok := SpanContextFromContext(ctx)
+		if ok != test.ok {
+			t.Errorf("%s: span context found = %v", test.name, ok)
+			continue
+		}
+		out := http.Header{}
+		InjectTraceParent(ctx, out)
+		if !ok {
+			if len(out) != 0 {
+				t.Errorf("%s: headers set without span context: %v", test.name, out)
+			}
+			continue
+		}
+		if !sc.Remote || sc.TraceState != test.state {
+			t.Errorf("%s: got %+v", test.name, sc)
+		}
+		if out.Get("traceparent") != tp || out.Get("tracestate") != test.state {
+			t.Errorf("%s: injected headers %v", test.name, out)
+		}
+	}
+}
+
+// tracingTestSpan records the attributes set on a span
+type tracingTestSpan struct {
+	attrs map[string]any
+	err   error
+	ended bool
+}
+
+func (s *tracingTestSpan) SetAttribute(key string, value any) { s.attrs[key] = value }
+func (s *tracingTestSpan) RecordError(err error)              { s.err = err }
+func (s *tracingTestSpan) End()                               { s.ended = true }
+func (s *tracingTestSpan) SpanContext() SpanContext {
+	return SpanContext{TraceId: [16]byte{1}, SpanId: [8]byte{2}, Flags: 1}
+}
+
+type tracingTestTracer struct {
+	spans []*tracingTestSpan
+}
+
+func (t *tracingTestTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	s := &tracingTestSpan{attrs: map[string]any{"name": name}}
+	t.spans = append(t.spans, s)
+	return ctx, s
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	rpcErr := &ErrorObject{Code: -32005, Message: "rate limited"}
+	tests := []struct {
+		name   string
+		err    error
+		expect map[string]any
+	}{
+		{"success", nil, map[string]any{"name": "eth_call", "rpc.method": "eth_call", "server.address": "node"}},
+		{"rpc error", rpcErr, map[string]any{"rpc.jsonrpc.error_code": -32005, "rpc.jsonrpc.error_message": "rate limited"}},
+		{"http error", &HTTPError{StatusCode: 502, Status: "502 Bad Gateway"}, map[string]any{"http.response.status_code": 502}},
+		{"rpc error with status", fmt.Errorf("%w (%w)", rpcErr, &HTTPError{StatusCode: 429}), map[string]any{"rpc.jsonrpc.error_code": -32005, "http.response.status_code": 429}},
+	}
+	for _, test := range tests {
+		tracer := &tracingTestTracer{}
+		var sent SpanContext
+		h := Chain(RequestHandlerFunc(func(ctx context.Context, req *Request) (json.RawMessage, error) {
+			sent, _ = SpanContextFromContext(ctx)
+			return json.RawMessage(`"0x"`), test.err
+		}), TracingMiddleware(tracer, "node"))
+		h.DoCtx(context.Background(), "eth_call")
+
+		if len(tracer.spans) != 1 {
+			t.Errorf("%s: %d spans started", test.name, len(tracer.spans))
+			continue
+		}
+		span := tracer.spans[0]
+		for k, v := range test.expect {
+			if span.attrs[k] != v {
+				t.Errorf("%s: attribute %s = %v, expected %v", test.name, k, span.attrs[k], v)
+			}
+		}
+		if !span.ended || span.err != test.err || sent != span.SpanContext() {
+			t.Errorf("%s: ended = %v, error = %v, propagated %+v", test.name, span.ended, span.err, sent)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package ethrpc
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ModChain/ethrpc/abi"
+)
+
+const (
+	LegacyTxType     = 0
+	DynamicFeeTxType = 2 // EIP-1559
+)
+
+// Transaction is an Ethereum transaction that can be signed locally
+type Transaction struct {
+	Type                 uint8
+	ChainId              uint64
+	Nonce                uint64
+	GasPrice             *big.Int // legacy transactions
+	MaxPriorityFeePerGas *big.Int // EIP-1559
+	MaxFeePerGas         *big.Int // EIP-1559
+	Gas                  uint64
+	To                   *abi.Address // nil for contract creation
+	Value                *big.Int
+	Data                 []byte
+
+	// signature values, set by Sign
+	V, R, S *big.Int
+}
+
+// Fees returns the fees of the transaction
+func (tx *Transaction) Fees() *TxFees {
+	if tx.Type == LegacyTxType {
+		return &TxFees{GasPrice: tx.GasPrice}
+	}
+	return &TxFees{MaxFeePerGas: tx.MaxFeePerGas, MaxPriorityFeePerGas: tx.MaxPriorityFeePerGas}
+}
+
+// SetFees updates the fees of the transaction, for example after calling [ReplacementPolicy.Bump].
+// The signature is reset and the transaction will need to be signed again.
+func (tx *Transaction) SetFees(fees *TxFees) {
+	if tx.Type == LegacyTxType {
+		tx.GasPrice = fees.GasPrice
+	} else {
+		tx.MaxFeePerGas = fees.MaxFeePerGas
+		tx.MaxPriorityFeePerGas = fees.MaxPriorityFeePerGas
+	}
+	tx.V, tx.R, tx.S = nil, nil, nil
+}
+
+func (tx *Transaction) to() []byte {
+	if tx.To == nil {
+		return rlpBytes(nil)
+	}
+	return rlpBytes(tx.To[:])
+}
+
+// SigningHash returns the hash that needs to be signed for this transaction
+func (tx *Transaction) SigningHash() []byte {
+	if tx.Type == LegacyTxType {
+		// EIP-155
+		return abi.Keccak256(rlpList(
+			rlpUint(tx.Nonce),
+			rlpBig(tx.GasPrice),
+			rlpUint(tx.Gas),
+			tx.to(),
+			rlpBig(tx.Value),
+			rlpBytes(tx.Data),
+			rlpUint(tx.ChainId),
+			rlpUint(0),
+			rlpUint(0),
+		))
+	}
+	return abi.Keccak256([]byte{tx.Type}, rlpList(tx.dynamicFeeFields()...))
+}
+
+func (tx *Transaction) dynamicFeeFields() [][]byte {
+	return [][]byte{
+		rlpUint(tx.ChainId),
+		rlpUint(tx.Nonce),
+		rlpBig(tx.MaxPriorityFeePerGas),
+		rlpBig(tx.MaxFeePerGas),
+		rlpUint(tx.Gas),
+		tx.to(),
+		rlpBig(tx.Value),
+		rlpBytes(tx.Data),
+		rlpList(), // access list
+	}
+}
+
+// Sign signs the transaction using the given signer
+func (tx *Transaction) Sign(s Signer) error {
+	sig, err := s.SignHash(tx.SigningHash())
+	if err != nil {
+		return err
+	}
+	if len(sig) != 65 {
+		return ErrInvalidSignature
+	}
+	tx.R = new(big.Int).SetBytes(sig[:32])
+	tx.S = new(big.Int).SetBytes(sig[32:64])
+	v := uint64(sig[64])
+	if tx.Type == LegacyTxType {
+		v += tx.ChainId*2 + 35
+	}
+	tx.V = new(big.Int).SetUint64(v)
+	return nil
+}
+
+// MarshalBinary returns the signed transaction as accepted by eth_sendRawTransaction
+func (tx *Transaction) MarshalBinary() ([]byte, error) {
+	if tx.V == nil || tx.R == nil || tx.S == nil {
+		return nil, errors.New("transaction is not signed")
+	}
+	if tx.Type == LegacyTxType {
+		return rlpList(
+			rlpUint(tx.Nonce),
+			rlpBig(tx.GasPrice),
+			rlpUint(tx.Gas),
+			tx.to(),
+			rlpBig(tx.Value),
+			rlpBytes(tx.Data),
+			rlpBig(tx.V),
+			rlpBig(tx.R),
+			rlpBig(tx.S),
+		), nil
+	}
+	fields := append(tx.dynamicFeeFields(), rlpBig(tx.V), rlpBig(tx.R), rlpBig(tx.S))
+	return append([]byte{tx.Type}, rlpList(fields...)...), nil
+}
+
+// Hash returns the hash of the signed transaction
+func (tx *Transaction) Hash() (abi.Hash, error) {
+	buf, err := tx.MarshalBinary()
+	if err != nil {
+		return abi.Hash{}, err
+	}
+	return abi.Keccak256Hash(buf), nil
+}
+
+// CreateAddress returns the address of a contract deployed by the given account with the
+// given nonce
+func CreateAddress(from abi.Address, nonce uint64) abi.Address {
+	var res abi.Address
+	copy(res[:], abi.Keccak256(rlpList(rlpBytes(from[:]), rlpUint(nonce)))[12:])
+	return res
+}
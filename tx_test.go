@@ -0,0 +1,66 @@
+package ethrpc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/ModChain/ethrpc/abi"
+)
+
+// example transaction from EIP-155
+func TestTransactionSignEIP155(t *testing.T) {
+	key, err := ParsePrivateKey("4646464646464646464646464646464646464646464646464646464646464646")
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := abi.MustParseAddress("0x3535353535353535353535353535353535353535")
+	value, _ := new(big.Int).SetString("1000000000000000000", 10)
+	tx := &Transaction{
+		Type:     LegacyTxType,
+		ChainId:  1,
+		Nonce:    9,
+		GasPrice: big.NewInt(20_000_000_000),
+		Gas:      21000,
+		To:       &to,
+		Value:    value,
+	}
+
+	wantHash := "daf5a779ae972f972197303d7b574746c7ef83eadac0f2791ad23db92e4c8e53"
+	if got := hex.EncodeToString(tx.SigningHash()); got != wantHash {
+		t.Errorf("signing hash: got %s, want %s", got, wantHash)
+	}
+
+	if err := tx.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+	wantR, _ := new(big.Int).SetString("18515461264373351373200002665853028612451056578545711640558177340181847433846", 10)
+	wantS, _ := new(big.Int).SetString("46948507304638947509940763649030358759909902576025900602547168820602576006531", 10)
+	if tx.V.Uint64() != 37 || tx.R.Cmp(wantR) != 0 || tx.S.Cmp(wantS) != 0 {
+		t.Errorf("signature: got v=%s r=%s s=%s", tx.V, tx.R, tx.S)
+	}
+
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := hex.DecodeString("f86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008025a028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276a067cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83")
+	if !bytes.Equal(raw, want) {
+		t.Errorf("signed transaction: got %x, want %x", raw, want)
+	}
+
+	// the sender must be recoverable from the signature
+	sig := append(append(leftPad32(tx.R.Bytes()), leftPad32(tx.S.Bytes())...), byte(tx.V.Uint64()-35-2*tx.ChainId))
+	from, err := RecoverAddress(tx.SigningHash(), sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != key.Address() {
+		t.Errorf("recovered %s, want %s", from, key.Address())
+	}
+}
+
+func leftPad32(buf []byte) []byte {
+	return append(make([]byte, 32-len(buf)), buf...)
+}
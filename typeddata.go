@@ -0,0 +1,339 @@
+package ethrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ModChain/ethrpc/abi"
+)
+
+// TypedDataField is a field of an EIP-712 struct type
+type TypedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TypedDataDomain is the EIP-712 domain. Only the set fields are part of the domain.
+type TypedDataDomain struct {
+	Name              string       `json:"name,omitempty"`
+	Version           string       `json:"version,omitempty"`
+	ChainId           *big.Int     `json:"chainId,omitempty"`
+	VerifyingContract *abi.Address `json:"verifyingContract,omitempty"`
+	Salt              *abi.Hash    `json:"salt,omitempty"`
+}
+
+// TypedData is an EIP-712 typed data message, in the format used by eth_signTypedData_v4
+type TypedData struct {
+	Types       map[string][]TypedDataField `json:"types"`
+	PrimaryType string                      `json:"primaryType"`
+	Domain      TypedDataDomain             `json:"domain"`
+	Message     map[string]any              `json:"message"`
+}
+
+// UnmarshalJSON decodes typed data, keeping numbers as [json.Number] to avoid precision loss
+func (td *TypedData) UnmarshalJSON(b []byte) error {
+	type typedData TypedData
+	var obj struct {
+		typedData
+		Domain struct {
+			TypedDataDomain
+			ChainId any `json:"chainId,omitempty"`
+		} `json:"domain"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&obj); err != nil {
+		return err
+	}
+	*td = TypedData(obj.typedData)
+	td.Domain = obj.Domain.TypedDataDomain
+	if obj.Domain.ChainId != nil {
+		// chainId can be a number, or a decimal or hex string
+		n, ok := new(big.Int).SetString(fmt.Sprint(obj.Domain.ChainId), 0)
+		if !ok {
+			return fmt.Errorf("invalid chainId %v", obj.Domain.ChainId)
+		}
+		td.Domain.ChainId = n
+	}
+	return nil
+}
+
+// Fields returns the EIP712Domain type matching the set fields of the domain
+func (d *TypedDataDomain) Fields() []TypedDataField {
+	var res []TypedDataField
+	if d.Name != "" {
+		res = append(res, TypedDataField{"name", "string"})
+	}
+	if d.Version != "" {
+		res = append(res, TypedDataField{"version", "string"})
+	}
+	if d.ChainId != nil {
+		res = append(res, TypedDataField{"chainId", "uint256"})
+	}
+	if d.VerifyingContract != nil {
+		res = append(res, TypedDataField{"verifyingContract", "address"})
+	}
+	if d.Salt != nil {
+		res = append(res, TypedDataField{"salt", "bytes32"})
+	}
+	return res
+}
+
+// Map returns the set fields of the domain as a map
+func (d *TypedDataDomain) Map() map[string]any {
+	res := make(map[string]any)
+	if d.Name != "" {
+		res["name"] = d.Name
+	}
+	if d.Version != "" {
+		res["version"] = d.Version
+	}
+	if d.ChainId != nil {
+		res["chainId"] = d.ChainId
+	}
+	if d.VerifyingContract != nil {
+		res["verifyingContract"] = *d.VerifyingContract
+	}
+	if d.Salt != nil {
+		res["salt"] = *d.Salt
+	}
+	return res
+}
+
+// types returns the types including EIP712Domain, derived from the domain if missing
+func (td *TypedData) types() map[string][]TypedDataField {
+	if _, ok := td.Types["EIP712Domain"]; ok {
+		return td.Types
+	}
+	res := make(map[string][]TypedDataField, len(td.Types)+1)
+	for k, v := range td.Types {
+		res[k] = v
+	}
+	res["EIP712Domain"] = td.Domain.Fields()
+	return res
+}
+
+// Hash returns the EIP-712 hash of the message, which is the hash being signed
+func (td *TypedData) Hash() (abi.Hash, error) {
+	domain, err := td.DomainSeparator()
+	if err != nil {
+		return abi.Hash{}, err
+	}
+	msg, err := td.HashStruct(td.PrimaryType, td.Message)
+	if err != nil {
+		return abi.Hash{}, err
+	}
+	return abi.Keccak256Hash([]byte{0x19, 0x01}, domain[:], msg[:]), nil
+}
+
+// DomainSeparator returns the hash of the domain
+func (td *TypedData) DomainSeparator() (abi.Hash, error) {
+	return td.HashStruct("EIP712Domain", td.Domain.Map())
+}
+
+// HashStruct returns the hash of data, encoded as the given struct type
+func (td *TypedData) HashStruct(typ string, data map[string]any) (abi.Hash, error) {
+	buf, err := td.encodeData(td.types(), typ, data)
+	if err != nil {
+		return abi.Hash{}, err
+	}
+	return abi.Keccak256Hash(buf), nil
+}
+
+// EncodeType returns the encoding of the given struct type and the types it references, such
+// as "Mail(Person from,Person to,string contents)Person(string name,address wallet)"
+func (td *TypedData) EncodeType(typ string) string {
+	types := td.types()
+	deps := map[string]bool{}
+	typedDataDeps(types, typ, deps)
+	delete(deps, typ)
+	list := make([]string, 0, len(deps))
+	for dep := range deps {
+		list = append(list, dep)
+	}
+	sort.Strings(list)
+
+	var res strings.Builder
+	for _, name := range append([]string{typ}, list...) {
+		res.WriteString(name)
+		res.WriteByte('(')
+		for n, f := range types[name] {
+			if n > 0 {
+				res.WriteByte(',')
+			}
+			res.WriteString(f.Type + " " + f.Name)
+		}
+		res.WriteByte(')')
+	}
+	return res.String()
+}
+
+// TypeHash returns the hash of the encoding of the given struct type
+func (td *TypedData) TypeHash(typ string) abi.Hash {
+	return abi.Keccak256Hash([]byte(td.EncodeType(typ)))
+}
+
+func typedDataDeps(types map[string][]TypedDataField, typ string, deps map[string]bool) {
+	typ = typedDataBase(typ)
+	if deps[typ] {
+		return
+	}
+	fields, ok := types[typ]
+	if !ok {
+		return
+	}
+	deps[typ] = true
+	for _, f := range fields {
+		typedDataDeps(types, f.Type, deps)
+	}
+}
+
+// typedDataBase strips array suffixes from a type
+func typedDataBase(typ string) string {
+	if pos := strings.IndexByte(typ, '['); pos != -1 {
+		return typ[:pos]
+	}
+	return typ
+}
+
+func (td *TypedData) encodeData(types map[string][]TypedDataField, typ string, data map[string]any) ([]byte, error) {
+	fields, ok := types[typ]
+	if !ok {
+		return nil, fmt.Errorf("typed data: unknown type %s", typ)
+	}
+	hash := td.TypeHash(typ)
+	res := append([]byte(nil), hash[:]...)
+	for _, f := range fields {
+		buf, err := td.encodeValue(types, f.Type, data[f.Name])
+		if err != nil {
+			return nil, fmt.Errorf("typed data: %s.%s: %w", typ, f.Name, err)
+		}
+		res = append(res, buf...)
+	}
+	return res, nil
+}
+
+// encodeValue returns the 32 bytes encoding of a single value
+func (td *TypedData) encodeValue(types map[string][]TypedDataField, typ string, v any) ([]byte, error) {
+	if pos := strings.LastIndexByte(typ, '['); pos != -1 && strings.HasSuffix(typ, "]") {
+		// arrays are encoded as the hash of the concatenated encoding of their elements
+		var list []any
+		switch l := v.(type) {
+		case nil:
+		case []any:
+			list = l
+		default:
+			return nil, fmt.Errorf("expected array for %s, got %T", typ, v)
+		}
+		var buf []byte
+		for _, elem := range list {
+			enc, err := td.encodeValue(types, typ[:pos], elem)
+			if err != nil {
+				return nil, err
+			}
+			buf = append(buf, enc...)
+		}
+		return abi.Keccak256(buf), nil
+	}
+	if _, ok := types[typ]; ok {
+		var data map[string]any
+		if v != nil {
+			var ok bool
+			if data, ok = v.(map[string]any); !ok {
+				return nil, fmt.Errorf("expected object for %s, got %T", typ, v)
+			}
+		}
+		buf, err := td.encodeData(types, typ, data)
+		if err != nil {
+			return nil, err
+		}
+		return abi.Keccak256(buf), nil
+	}
+
+	t, err := abi.ParseType(typ)
+	if err != nil {
+		return nil, err
+	}
+	switch t.Kind {
+	case abi.StringKind:
+		if s, ok := v.(string); ok {
+			return abi.Keccak256([]byte(s)), nil
+		}
+		fallthrough
+	case abi.BytesKind:
+		buf, err := typedDataBytes(v)
+		if err != nil {
+			return nil, err
+		}
+		return abi.Keccak256(buf), nil
+	case abi.UintKind, abi.IntKind:
+		if f, ok := v.(float64); ok {
+			// numbers decoded by encoding/json without UseNumber
+			n, acc := big.NewFloat(f).Int(nil)
+			if acc != big.Exact {
+				return nil, fmt.Errorf("invalid integer %v", f)
+			}
+			v = n
+		}
+	}
+	return t.Pack(v)
+}
+
+func typedDataBytes(v any) ([]byte, error) {
+	switch b := v.(type) {
+	case []byte:
+		return b, nil
+	case string:
+		return hex.DecodeString(strings.TrimPrefix(b, "0x"))
+	case nil:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("expected bytes, got %T", v)
+}
+
+// Sign signs the typed data with the given signer, and returns a 65 bytes signature with V
+// being 27 or 28 as returned by eth_signTypedData_v4
+func (td *TypedData) Sign(s Signer) ([]byte, error) {
+	hash, err := td.Hash()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := s.SignHash(hash[:])
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+// Recover returns the address of the account that signed the typed data
+func (td *TypedData) Recover(sig []byte) (abi.Address, error) {
+	hash, err := td.Hash()
+	if err != nil {
+		return abi.Address{}, err
+	}
+	return RecoverAddress(hash[:], sig)
+}
+
+// Verify checks that sig is a signature of the typed data by addr
+func (td *TypedData) Verify(addr abi.Address, sig []byte) error {
+	signer, err := td.Recover(sig)
+	if err != nil {
+		return err
+	}
+	if signer != addr {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// SignTypedData asks the node to sign the typed data with the key of the given account
+func (a *Api) SignTypedData(ctx context.Context, addr abi.Address, td *TypedData) ([]byte, error) {
+	return ReadBytes(a.Handler.DoCtx(ctx, "eth_signTypedData_v4", addr, td))
+}
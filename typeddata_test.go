@@ -0,0 +1,104 @@
+package ethrpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/ModChain/ethrpc/abi"
+)
+
+// example from EIP-712
+const typedDataMail = `{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],
+		"Person": [
+			{"name": "name", "type": "string"},
+			{"name": "wallet", "type": "address"}
+		],
+		"Mail": [
+			{"name": "from", "type": "Person"},
+			{"name": "to", "type": "Person"},
+			{"name": "contents", "type": "string"}
+		]
+	},
+	"primaryType": "Mail",
+	"domain": {
+		"name": "Ether Mail",
+		"version": "1",
+		"chainId": 1,
+		"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+	},
+	"message": {
+		"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+		"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+		"contents": "Hello, Bob!"
+	}
+}`
+
+func TestTypedDataMail(t *testing.T) {
+	var td TypedData
+	if err := json.Unmarshal([]byte(typedDataMail), &td); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := td.EncodeType("Mail"), "Mail(Person from,Person to,string contents)Person(string name,address wallet)"; got != want {
+		t.Errorf("EncodeType: got %s, want %s", got, want)
+	}
+	if got, want := td.TypeHash("Mail").Hex(), "0xa0cedeb2dc280ba39b857546d74f5549c3a1d7bdc2dd96bf881f76108e23dac2"; got != want {
+		t.Errorf("TypeHash: got %s, want %s", got, want)
+	}
+
+	sep, err := td.DomainSeparator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sep.Hex(), "0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f"; got != want {
+		t.Errorf("DomainSeparator: got %s, want %s", got, want)
+	}
+
+	msg, err := td.HashStruct("Mail", td.Message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := msg.Hex(), "0xc52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e"; got != want {
+		t.Errorf("HashStruct: got %s, want %s", got, want)
+	}
+
+	hash, err := td.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := hash.Hex(), "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2"; got != want {
+		t.Errorf("Hash: got %s, want %s", got, want)
+	}
+
+	// the example is signed with keccak256("cow")
+	key, err := NewPrivateKeySigner(abi.Keccak256([]byte("cow")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := td.Sign(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d" +
+		"07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b91562" +
+		"1c"
+	if got := hex.EncodeToString(sig); got != want {
+		t.Errorf("Sign: got %s, want %s", got, want)
+	}
+
+	signer := abi.MustParseAddress("0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826")
+	if err := td.Verify(signer, sig); err != nil {
+		t.Errorf("Verify: %s", err)
+	}
+	if err := td.Verify(abi.MustParseAddress("0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"), sig); err == nil {
+		t.Error("Verify accepted the signature for another address")
+	}
+}
@@ -0,0 +1,291 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ModChain/ethrpc/abi"
+)
+
+// FilterKind is the kind of a server-side filter
+type FilterKind int
+
+const (
+	LogFilter FilterKind = iota
+	BlockFilter
+	PendingTransactionFilter
+)
+
+// NewFilter installs a log filter on the server and returns its id
+func (a *Api) NewFilter(ctx context.Context, q *FilterQuery) (string, error) {
+	return ReadString(a.Handler.DoCtx(ctx, "eth_newFilter", q))
+}
+
+// NewBlockFilter installs a filter notifying of new block hashes and returns its id
+func (a *Api) NewBlockFilter(ctx context.Context) (string, error) {
+	return ReadString(a.Handler.DoCtx(ctx, "eth_newBlockFilter"))
+}
+
+// NewPendingTransactionFilter installs a filter notifying of new pending transaction hashes
+// and returns its id
+func (a *Api) NewPendingTransactionFilter(ctx context.Context) (string, error) {
+	return ReadString(a.Handler.DoCtx(ctx, "eth_newPendingTransactionFilter"))
+}
+
+// GetFilterChanges returns the changes since the last poll of the given filter, which is a
+// list of logs for log filters, and a list of hashes for block and pending transaction filters
+func (a *Api) GetFilterChanges(ctx context.Context, id string) (json.RawMessage, error) {
+	return a.Handler.DoCtx(ctx, "eth_getFilterChanges", id)
+}
+
+// GetFilterLogs returns all the logs matching the given log filter
+func (a *Api) GetFilterLogs(ctx context.Context, id string) ([]*Log, error) {
+	return ReadAs[[]*Log](a.Handler.DoCtx(ctx, "eth_getFilterLogs", id))
+}
+
+// UninstallFilter removes the given filter from the server
+func (a *Api) UninstallFilter(ctx context.Context, id string) (bool, error) {
+	return ReadAs[bool](a.Handler.DoCtx(ctx, "eth_uninstallFilter", id))
+}
+
+// Filter polls a server-side filter and delivers its results on a channel. If the server
+// forgets about the filter, it is installed again transparently.
+type Filter struct {
+	Logs   <-chan *Log     // log filters
+	Hashes <-chan abi.Hash // block and pending transaction filters
+
+	api      *Api
+	kind     FilterKind
+	query    FilterQuery
+	interval time.Duration
+	logs     chan *Log
+	hashes   chan abi.Hash
+	cancel   func()
+	done     chan struct{}
+	id       string
+
+	// last delivered log, used to avoid duplicates when reinstalling log filters
+	seen      bool
+	lastBlock uint64
+	lastIndex uint64
+
+	lk  sync.Mutex
+	err error
+}
+
+// WatchLogs installs a log filter and polls it every interval (4 seconds if zero), delivering
+// logs on the Logs channel until ctx is cancelled or Close is called
+func (a *Api) WatchLogs(ctx context.Context, q *FilterQuery, interval time.Duration) (*Filter, error) {
+	return a.watch(ctx, LogFilter, q, interval)
+}
+
+// WatchBlocks installs a block filter and delivers new block hashes on the Hashes channel
+func (a *Api) WatchBlocks(ctx context.Context, interval time.Duration) (*Filter, error) {
+	return a.watch(ctx, BlockFilter, nil, interval)
+}
+
+// WatchPendingTransactions installs a pending transaction filter and delivers new transaction
+// hashes on the Hashes channel
+func (a *Api) WatchPendingTransactions(ctx context.Context, interval time.Duration) (*Filter, error) {
+	return a.watch(ctx, PendingTransactionFilter, nil, interval)
+}
+
+func (a *Api) watch(ctx context.Context, kind FilterKind, q *FilterQuery, interval time.Duration) (*Filter, error) {
+	if interval <= 0 {
+		interval = 4 * time.Second
+	}
+	f := &Filter{api: a, kind: kind, interval: interval, done: make(chan struct{})}
+	if q != nil {
+		f.query = *q
+	}
+	if err := f.install(ctx); err != nil {
+		return nil, err
+	}
+
+	if kind == LogFilter {
+		f.logs = make(chan *Log)
+		f.Logs = f.logs
+	} else {
+		f.hashes = make(chan abi.Hash)
+		f.Hashes = f.hashes
+	}
+
+	ctx, f.cancel = context.WithCancel(ctx)
+	go f.run(ctx)
+	return f, nil
+}
+
+// install installs the filter on the server
+func (f *Filter) install(ctx context.Context) error {
+	var (
+		id  string
+		err error
+	)
+	switch f.kind {
+	case LogFilter:
+		q := f.query
+		if f.seen {
+			// resume from the last block we delivered logs for
+			q.FromBlock = BlockTag(f.lastBlock)
+		}
+		id, err = f.api.NewFilter(ctx, &q)
+	case BlockFilter:
+		id, err = f.api.NewBlockFilter(ctx)
+	default:
+		id, err = f.api.NewPendingTransactionFilter(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	f.id = id
+	return nil
+}
+
+func (f *Filter) run(ctx context.Context) {
+	defer close(f.done)
+	defer func() {
+		// use a fresh context as ours is cancelled already
+		uctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if _, err := f.api.UninstallFilter(uctx, f.id); err != nil {
+			f.setErr(err)
+		}
+		if f.logs != nil {
+			close(f.logs)
+		} else {
+			close(f.hashes)
+		}
+	}()
+
+	t := time.NewTicker(f.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		if err := f.poll(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			f.setErr(err)
+		}
+	}
+}
+
+// poll fetches the changes of the filter and delivers them
+func (f *Filter) poll(ctx context.Context) error {
+	res, err := f.api.GetFilterChanges(ctx, f.id)
+	if err != nil {
+		if !isFilterNotFound(err) {
+			return err
+		}
+		if err := f.install(ctx); err != nil {
+			return err
+		}
+		if f.kind != LogFilter {
+			// hashes that happened while the filter was missing are lost
+			return nil
+		}
+		// changes start from the installation, fetch the logs we may have missed
+		logs, err := f.api.GetFilterLogs(ctx, f.id)
+		if err != nil {
+			return err
+		}
+		return f.deliverLogs(ctx, logs)
+	}
+
+	if f.kind == LogFilter {
+		var logs []*Log
+		if err := json.Unmarshal(res, &logs); err != nil {
+			return err
+		}
+		return f.deliverLogs(ctx, logs)
+	}
+
+	var hashes []abi.Hash
+	if err := json.Unmarshal(res, &hashes); err != nil {
+		return err
+	}
+	for _, h := range hashes {
+		select {
+		case f.hashes <- h:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (f *Filter) deliverLogs(ctx context.Context, logs []*Log) error {
+	for _, l := range logs {
+		if f.seen && !l.Removed && (l.BlockNumber < f.lastBlock || l.BlockNumber == f.lastBlock && l.LogIndex <= f.lastIndex) {
+			// already delivered before the filter was reinstalled
+			continue
+		}
+		select {
+		case f.logs <- l:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		switch {
+		case !l.Removed:
+			f.seen = true
+			f.lastBlock, f.lastIndex = l.BlockNumber, l.LogIndex
+		case f.seen && (l.BlockNumber < f.lastBlock || l.BlockNumber == f.lastBlock && l.LogIndex <= f.lastIndex):
+			// the log may be added again on the new chain, only logs before it count as delivered
+			f.rewind(l.BlockNumber, l.LogIndex)
+		}
+	}
+	return nil
+}
+
+// rewind marks the logs from the given position onwards as not delivered
+func (f *Filter) rewind(block, index uint64) {
+	switch {
+	case index > 0:
+		f.lastBlock, f.lastIndex = block, index-1
+	case block > 0:
+		f.lastBlock, f.lastIndex = block-1, math.MaxUint64
+	default:
+		f.seen = false
+	}
+}
+
+func (f *Filter) setErr(err error) {
+	f.lk.Lock()
+	defer f.lk.Unlock()
+	f.err = err
+}
+
+// Err returns the last error that happened while polling, if any. Polling continues after errors.
+func (f *Filter) Err() error {
+	f.lk.Lock()
+	defer f.lk.Unlock()
+	return f.err
+}
+
+// Close stops polling and uninstalls the filter. The channel is closed once done. If the filter
+// could not be uninstalled, Err returns the error.
+func (f *Filter) Close() {
+	f.cancel()
+	<-f.done
+}
+
+// isFilterNotFound returns true if the server does not know about the filter anymore
+func isFilterNotFound(err error) bool {
+	var obj *ErrorObject
+	if !errors.As(err, &obj) {
+		return false
+	}
+	msg := strings.ToLower(obj.Message)
+	return strings.Contains(msg, "filter not found") || strings.Contains(msg, "filter does not exist") || strings.Contains(msg, "filter doesn't exist")
+}
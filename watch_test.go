@@ -0,0 +1,162 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// watchTestNode is a handler answering filter requests from a script. Each call to
+// eth_getFilterChanges returns the next entry of changes, and nil entries fail with
+// "filter not found" so the filter gets reinstalled.
+type watchTestNode struct {
+	changes   [][]*Log
+	logs      []*Log // returned by eth_getFilterLogs
+	uninstall error
+
+	lk       sync.Mutex
+	installs []string // FromBlock of installed filters
+}
+
+func (n *watchTestNode) SendCtx(ctx context.Context, req *Request) (json.RawMessage, error) {
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	switch req.Method {
+	case "eth_newFilter":
+		q := req.Params.([]any)[0].(*FilterQuery)
+		n.installs = append(n.installs, q.FromBlock)
+		return json.Marshal("0x" + strconv.Itoa(len(n.installs)))
+	case "eth_getFilterChanges":
+		if len(n.changes) == 0 {
+			return json.RawMessage("[]"), nil
+		}
+		logs := n.changes[0]
+		n.changes = n.changes[1:]
+		if logs == nil {
+			return nil, &ErrorObject{Code: -32000, Message: "filter not found"}
+		}
+		return json.Marshal(logs)
+	case "eth_getFilterLogs":
+		return json.Marshal(n.logs)
+	case "eth_uninstallFilter":
+		if n.uninstall != nil {
+			return nil, n.uninstall
+		}
+		return json.RawMessage("true"), nil
+	}
+	return nil, &ErrorObject{Code: errMethodNotFound, Message: "method not found"}
+}
+
+func (n *watchTestNode) DoCtx(ctx context.Context, method string, args ...any) (json.RawMessage, error) {
+	return n.SendCtx(ctx, NewRequest(method, args...))
+}
+
+func TestWatchLogs(t *testing.T) {
+	l := func(block, index uint64, removed bool) *Log {
+		return &Log{BlockNumber: block, LogIndex: index, Removed: removed}
+	}
+	tests := []struct {
+		name     string
+		changes  [][]*Log
+		logs     []*Log
+		expect   []*Log
+		installs []string
+	}{
+		{
+			"no reinstall",
+			[][]*Log{{l(1, 0, false)}, {l(2, 0, false), l(2, 1, false)}},
+			nil,
+			[]*Log{l(1, 0, false), l(2, 0, false), l(2, 1, false)},
+			[]string{""},
+		},
+		{
+			"reinstall skips delivered logs",
+			[][]*Log{{l(1, 0, false), l(2, 0, false)}, nil},
+			[]*Log{l(2, 0, false), l(2, 1, false), l(3, 0, false)},
+			[]*Log{l(1, 0, false), l(2, 0, false), l(2, 1, false), l(3, 0, false)},
+			[]string{"", "0x2"},
+		},
+		{
+			"reinstall after removed log",
+			[][]*Log{{l(1, 0, false), l(2, 0, false), l(2, 1, false)}, {l(2, 1, true), l(2, 0, true)}, nil},
+			[]*Log{l(1, 0, false), l(2, 0, false), l(2, 1, false)},
+			[]*Log{l(1, 0, false), l(2, 0, false), l(2, 1, false), l(2, 1, true), l(2, 0, true), l(2, 0, false), l(2, 1, false)},
+			[]string{"", "0x1"},
+		},
+		{
+			"reinstall before any log",
+			[][]*Log{nil},
+			[]*Log{l(0, 0, false), l(1, 0, false)},
+			[]*Log{l(0, 0, false), l(1, 0, false)},
+			[]string{"", ""},
+		},
+	}
+	for _, test := range tests {
+		node := &watchTestNode{changes: test.changes, logs: test.logs}
+		api := &Api{node}
+		f, err := api.WatchLogs(context.Background(), &FilterQuery{}, time.Millisecond)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+		var res []*Log
+		timeout := time.After(5 * time.Second)
+	read:
+		for len(res) < len(test.expect) {
+			select {
+			case lg := <-f.Logs:
+				res = append(res, lg)
+			case <-timeout:
+				break read
+			}
+		}
+		// a few more polls to make sure nothing else is delivered
+		time.Sleep(10 * time.Millisecond)
+		go f.Close()
+		for lg := range f.Logs {
+			res = append(res, lg)
+		}
+
+		if len(res) != len(test.expect) {
+			t.Errorf("%s: got %d logs, expected %d", test.name, len(res), len(test.expect))
+			continue
+		}
+		for n, lg := range res {
+			if e := test.expect[n]; lg.BlockNumber != e.BlockNumber || lg.LogIndex != e.LogIndex || lg.Removed != e.Removed {
+				t.Errorf("%s: log %d is %+v, expected %+v", test.name, n, lg, test.expect[n])
+			}
+		}
+		node.lk.Lock()
+		installs := node.installs
+		node.lk.Unlock()
+		if len(installs) != len(test.installs) {
+			t.Errorf("%s: filter installed %d times, expected %d", test.name, len(installs), len(test.installs))
+			continue
+		}
+		for n, from := range installs {
+			if from != test.installs[n] {
+				t.Errorf("%s: install %d from %q, expected %q", test.name, n, from, test.installs[n])
+			}
+		}
+		if err := f.Err(); err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+		}
+	}
+}
+
+func TestWatchUninstallError(t *testing.T) {
+	fail := errors.New("connection refused")
+	api := &Api{&watchTestNode{uninstall: fail}}
+	f, err := api.WatchLogs(context.Background(), nil, time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	f.Close()
+	if err := f.Err(); !errors.Is(err, fail) {
+		t.Errorf("Err() = %v, expected %s", err, fail)
+	}
+}